@@ -3,19 +3,130 @@ package cmd
 import (
 	"context"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
 
 	"github.com/apex/log"
-	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/google/go-github/v64/github"
 	"github.com/nexthink-oss/ghup/internal/local"
 	"github.com/nexthink-oss/ghup/internal/remote"
 	"github.com/nexthink-oss/ghup/internal/util"
+	"github.com/nexthink-oss/ghup/pkg/choiceflag"
 	"github.com/pkg/errors"
 	"github.com/shurcooL/githubv4"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
+type fileChange struct {
+	Path string `json:"path"`
+	Hash string `json:"hash,omitempty"`
+	Url  string `json:"url,omitempty"`
+
+	// lfs is the Git LFS object referenced by the pointer planned for addition, if any
+	lfs *lfsUpload
+}
+
+// lfsUpload holds the content of a Git LFS object, to upload before committing its pointer
+type lfsUpload struct {
+	local.LFSObject
+	content []byte
+}
+
+type contentWarning struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Path    string `json:"path,omitempty"`
+}
+
+// contentWarnings collects non-fatal issues for structured output, logging each as it is added
+type contentWarnings []contentWarning
+
+func (w *contentWarnings) Add(code string, path string, format string, args ...interface{}) {
+	message := fmt.Sprintf(format, args...)
+	log.Warn(message)
+	*w = append(*w, contentWarning{
+		Code:    code,
+		Message: message,
+		Path:    path,
+	})
+}
+
+// contentPlan records the changes planned against a base commit, without committing them
+type contentPlan struct {
+	Base      string       `json:"base,omitempty"`
+	Additions []fileChange `json:"additions"`
+	Deletions []fileChange `json:"deletions"`
+}
+
+type contentResult struct {
+	Commit      string          `json:"commit,omitempty"`
+	Url         string          `json:"url,omitempty"`
+	Commits     []string        `json:"commits,omitempty"`
+	CommittedAt string          `json:"committed_at,omitempty"`
+	Unchanged   bool            `json:"unchanged,omitempty"`
+	PullRequest string          `json:"pull_request,omitempty"`
+	TreeUrl     string          `json:"tree_url,omitempty"`
+	Additions   []fileChange    `json:"additions"`
+	Deletions   []fileChange    `json:"deletions"`
+	Warnings    contentWarnings `json:"warnings"`
+}
+
+func (r contentResult) String() string {
+	m, err := json.Marshal(r)
+	if err != nil {
+		log.Error(errors.Wrap(err, "json.Marshal").Error())
+		return ""
+	}
+	return string(m)
+}
+
+// printContentResult writes the result to stdout in the configured output format
+func printContentResult(result contentResult) {
+	switch {
+	case viper.GetString("output") == "json":
+		fmt.Println(result)
+	case result.PullRequest != "":
+		fmt.Println(result.PullRequest)
+	case result.Unchanged:
+		fmt.Printf("%s (unchanged)\n", result.Url)
+	case result.Url != "":
+		fmt.Println(result.Url)
+	}
+	if viper.GetString("output") != "json" && viper.GetBool("print-urls") && result.CommittedAt != "" {
+		fmt.Printf("committed at %s\n", result.CommittedAt)
+	}
+	if viper.GetString("output") != "json" && result.TreeUrl != "" {
+		fmt.Println(result.TreeUrl)
+		for _, addition := range result.Additions {
+			fmt.Println(addition.Url)
+		}
+	}
+}
+
+// addBrowseUrls sets the browse URLs of the target branch and of each addition at the
+// resulting commit, on the host of the commit URL
+func addBrowseUrls(result *contentResult) {
+	commitUrl, err := url.Parse(result.Url)
+	if err != nil || result.Commit == "" {
+		return
+	}
+	base := url.URL{Scheme: commitUrl.Scheme, Host: commitUrl.Host}
+
+	result.TreeUrl = base.JoinPath(owner, repo, "tree", branch).String()
+	for i, addition := range result.Additions {
+		result.Additions[i].Url = base.JoinPath(owner, repo, "blob", result.Commit, addition.Path).String()
+	}
+}
+
 var contentCmd = &cobra.Command{
 	Use:     "content [flags] [<file-spec> ...]",
 	Short:   "Manage content via the GitHub V4 API",
@@ -25,10 +136,44 @@ var contentCmd = &cobra.Command{
 }
 
 func init() {
+	contentCmd.Flags().Bool("create-repo", false, "create missing target repository (private, with an initial commit)")
+	viper.BindPFlag("create-repo", contentCmd.Flags().Lookup("create-repo"))
+	viper.BindEnv("create-repo", "GHUP_CREATE_REPO")
+
+	contentCmd.Flags().String("template-repo", "", "with --create-repo, create from template repository `owner/repo`")
+	viper.BindPFlag("template-repo", contentCmd.Flags().Lookup("template-repo"))
+	viper.BindEnv("template-repo", "GHUP_TEMPLATE_REPO")
+
+	ownerType := choiceflag.NewChoiceFlag([]string{"auto", "user", "org"})
+	_ = ownerType.Set("auto")
+	contentCmd.Flags().Var(ownerType, "owner-type", "type of repository owner for --create-repo")
+	viper.BindPFlag("owner-type", contentCmd.Flags().Lookup("owner-type"))
+	viper.BindEnv("owner-type", "GHUP_OWNER_TYPE")
+
+	contentCmd.Flags().Bool("update-default-branch", false, "when seeding an empty repository, make target branch its default branch")
+	viper.BindPFlag("update-default-branch", contentCmd.Flags().Lookup("update-default-branch"))
+	viper.BindEnv("update-default-branch", "GHUP_UPDATE_DEFAULT_BRANCH")
+
 	contentCmd.Flags().Bool("create-branch", true, "create missing target branch")
 	viper.BindPFlag("create-branch", contentCmd.Flags().Lookup("create-branch"))
 	viper.BindEnv("create-branch", "GHUP_CREATE_BRANCH")
 
+	contentCmd.Flags().Bool("create-branch-always", false, "create missing target branch even if there is nothing to commit")
+	viper.BindPFlag("create-branch-always", contentCmd.Flags().Lookup("create-branch-always"))
+	viper.BindEnv("create-branch-always", "GHUP_CREATE_BRANCH_ALWAYS")
+
+	contentCmd.Flags().Int("resolve-branch-from-pr", 0, "target the head branch (and repository) of pull request `number`")
+	viper.BindPFlag("resolve-branch-from-pr", contentCmd.Flags().Lookup("resolve-branch-from-pr"))
+	viper.BindEnv("resolve-branch-from-pr", "GHUP_RESOLVE_BRANCH_FROM_PR")
+
+	contentCmd.Flags().String("expected-branch-head", "", "abort unless target branch is at commit `sha`")
+	viper.BindPFlag("expected-branch-head", contentCmd.Flags().Lookup("expected-branch-head"))
+	viper.BindEnv("expected-branch-head", "GHUP_EXPECTED_BRANCH_HEAD")
+
+	contentCmd.Flags().String("sync-with-base", "", "before committing, fast-forward or merge branch `name` into an existing target branch")
+	viper.BindPFlag("sync-with-base", contentCmd.Flags().Lookup("sync-with-base"))
+	viper.BindEnv("sync-with-base", "GHUP_SYNC_WITH_BASE")
+
 	contentCmd.Flags().String("pr-title", "", "create pull request iff target branch is created and title is specified")
 	viper.BindPFlag("pr-title", contentCmd.Flags().Lookup("pr-title"))
 	viper.BindEnv("pr-title", "GHUP_PR_TITLE")
@@ -45,8 +190,35 @@ func init() {
 	viper.BindPFlag("base-branch", contentCmd.Flags().Lookup("base-branch"))
 	viper.BindEnv("base-branch", "GHUP_BASE_BRANCH")
 
-	contentCmd.Flags().StringP("separator", "s", ":", "file-spec separator")
-	viper.BindPFlag("separator", contentCmd.Flags().Lookup("separator"))
+	basePreference := choiceflag.NewChoiceFlag([]string{"flag,default", "flag-only", "flag,target"})
+	_ = basePreference.Set("flag,default")
+	contentCmd.Flags().Var(basePreference, "base-preference", "base branch resolution order for a missing target branch")
+	viper.BindPFlag("base-preference", contentCmd.Flags().Lookup("base-preference"))
+	viper.BindEnv("base-preference", "GHUP_BASE_PREFERENCE")
+
+	contentCmd.PersistentFlags().Int("max-open-prs", 0, "refuse to open a pull request while the token owner has `count` open in the repository, unless forced (0 for unlimited)")
+	viper.BindPFlag("max-open-prs", contentCmd.PersistentFlags().Lookup("max-open-prs"))
+	viper.BindEnv("max-open-prs", "GHUP_MAX_OPEN_PRS")
+
+	contentCmd.PersistentFlags().StringP("separator", "s", ":", "file-spec separator")
+	viper.BindPFlag("separator", contentCmd.PersistentFlags().Lookup("separator"))
+	viper.BindEnv("separator", "GHUP_SEPARATOR")
+
+	contentCmd.Flags().Int64("max-fetch-size", 25<<20, "maximum size in `bytes` of each stdin or URL source (0 for unlimited)")
+	viper.BindPFlag("max-fetch-size", contentCmd.Flags().Lookup("max-fetch-size"))
+	viper.BindEnv("max-fetch-size", "GHUP_MAX_FETCH_SIZE")
+
+	contentCmd.Flags().Int("fetch-concurrency", 4, "maximum concurrent URL source fetches")
+	viper.BindPFlag("fetch-concurrency", contentCmd.Flags().Lookup("fetch-concurrency"))
+	viper.BindEnv("fetch-concurrency", "GHUP_FETCH_CONCURRENCY")
+
+	contentCmd.Flags().Duration("fetch-timeout", 30*time.Second, "maximum `duration` of each URL source fetch (0 for unlimited)")
+	viper.BindPFlag("fetch-timeout", contentCmd.Flags().Lookup("fetch-timeout"))
+	viper.BindEnv("fetch-timeout", "GHUP_FETCH_TIMEOUT")
+
+	contentCmd.Flags().String("content-root", "", "resolve relative local source paths of file-specs against `dir`")
+	viper.BindPFlag("content-root", contentCmd.Flags().Lookup("content-root"))
+	viper.BindEnv("content-root", "GHUP_CONTENT_ROOT")
 
 	contentCmd.Flags().StringSliceP("update", "u", []string{}, "`file-spec` to update")
 	viper.BindPFlag("update", contentCmd.Flags().Lookup("update"))
@@ -54,53 +226,722 @@ func init() {
 	contentCmd.Flags().StringSliceP("delete", "d", []string{}, "`file-path` to delete")
 	viper.BindPFlag("delete", contentCmd.Flags().Lookup("delete"))
 
+	contentCmd.Flags().Bool("from-status", false, "update paths modified or added in the local work tree, per git status")
+	viper.BindPFlag("from-status", contentCmd.Flags().Lookup("from-status"))
+	viper.BindEnv("from-status", "GHUP_FROM_STATUS")
+
+	contentCmd.Flags().Bool("include-deletions", false, "with --from-status, also delete paths deleted in the local work tree")
+	viper.BindPFlag("include-deletions", contentCmd.Flags().Lookup("include-deletions"))
+	viper.BindEnv("include-deletions", "GHUP_INCLUDE_DELETIONS")
+
+	contentCmd.Flags().String("tree", "", "commit existing tree `sha` as-is, skipping all file-specs")
+	viper.BindPFlag("tree", contentCmd.Flags().Lookup("tree"))
+	viper.BindEnv("tree", "GHUP_TREE")
+
+	contentCmd.Flags().StringSlice("force-path", []string{}, "update targets matching `glob` even if unchanged")
+	viper.BindPFlag("force-path", contentCmd.Flags().Lookup("force-path"))
+
+	contentCmd.Flags().Bool("windows-paths", false, "convert backslashes in target paths to slashes")
+	viper.BindPFlag("windows-paths", contentCmd.Flags().Lookup("windows-paths"))
+	viper.BindEnv("windows-paths", "GHUP_WINDOWS_PATHS")
+
+	contentCmd.Flags().Bool("respect-gitignore", false, "warn about file-specs naming a locally gitignored file (still including it)")
+	viper.BindPFlag("respect-gitignore", contentCmd.Flags().Lookup("respect-gitignore"))
+	viper.BindEnv("respect-gitignore", "GHUP_RESPECT_GITIGNORE")
+
+	contentCmd.Flags().Bool("require-clean-worktree", false, "abort if the local work tree has untracked or unstaged changes not being pushed")
+	viper.BindPFlag("require-clean-worktree", contentCmd.Flags().Lookup("require-clean-worktree"))
+	viper.BindEnv("require-clean-worktree", "GHUP_REQUIRE_CLEAN_WORKTREE")
+
+	contentCmd.Flags().String("delete-missing-from", "", "delete paths listed in `manifest` but absent from the update set")
+	viper.BindPFlag("delete-missing-from", contentCmd.Flags().Lookup("delete-missing-from"))
+	viper.BindEnv("delete-missing-from", "GHUP_DELETE_MISSING_FROM")
+
+	contentCmd.Flags().String("since-commit", "", "only consider updates to paths changed on target branch since `sha`")
+	viper.BindPFlag("since-commit", contentCmd.Flags().Lookup("since-commit"))
+	viper.BindEnv("since-commit", "GHUP_SINCE_COMMIT")
+
+	contentCmd.Flags().Bool("lfs", false, "commit Git LFS pointers for LFS-tracked additions, uploading their content")
+	viper.BindPFlag("lfs", contentCmd.Flags().Lookup("lfs"))
+	viper.BindEnv("lfs", "GHUP_LFS")
+
+	contentCmd.Flags().StringSlice("lfs-pattern", []string{}, "additional LFS-tracked `pattern`")
+	viper.BindPFlag("lfs-pattern", contentCmd.Flags().Lookup("lfs-pattern"))
+
+	contentCmd.Flags().Bool("fail-on-binary", false, "abort if any addition is binary")
+	viper.BindPFlag("fail-on-binary", contentCmd.Flags().Lookup("fail-on-binary"))
+	viper.BindEnv("fail-on-binary", "GHUP_FAIL_ON_BINARY")
+
+	contentCmd.Flags().Bool("validate-yaml", false, "abort if any .yaml or .yml addition is not valid YAML")
+	viper.BindPFlag("validate-yaml", contentCmd.Flags().Lookup("validate-yaml"))
+	viper.BindEnv("validate-yaml", "GHUP_VALIDATE_YAML")
+
+	contentCmd.Flags().Bool("validate-json", false, "abort if any .json addition is not valid JSON")
+	viper.BindPFlag("validate-json", contentCmd.Flags().Lookup("validate-json"))
+	viper.BindEnv("validate-json", "GHUP_VALIDATE_JSON")
+
+	contentCmd.Flags().Bool("validate-all", false, "apply --validate-yaml/--validate-json to all non-binary additions")
+	viper.BindPFlag("validate-all", contentCmd.Flags().Lookup("validate-all"))
+	viper.BindEnv("validate-all", "GHUP_VALIDATE_ALL")
+
+	contentCmd.Flags().Bool("scan-secrets", false, "refuse non-binary additions containing possible secrets, unless forced")
+	viper.BindPFlag("scan-secrets", contentCmd.Flags().Lookup("scan-secrets"))
+	viper.BindEnv("scan-secrets", "GHUP_SCAN_SECRETS")
+
+	contentCmd.Flags().String("secrets-allowlist", "", "with --scan-secrets, ignore matches of the regular expressions in `file`, one per line")
+	viper.BindPFlag("secrets-allowlist", contentCmd.Flags().Lookup("secrets-allowlist"))
+	viper.BindEnv("secrets-allowlist", "GHUP_SECRETS_ALLOWLIST")
+
+	contentCmd.Flags().Bool("check-duplicates", false, "warn if any addition's content already exists elsewhere on target branch")
+	viper.BindPFlag("check-duplicates", contentCmd.Flags().Lookup("check-duplicates"))
+	viper.BindEnv("check-duplicates", "GHUP_CHECK_DUPLICATES")
+
+	contentCmd.Flags().Bool("fail-on-duplicate", false, "abort if any addition's content already exists elsewhere on target branch")
+	viper.BindPFlag("fail-on-duplicate", contentCmd.Flags().Lookup("fail-on-duplicate"))
+	viper.BindEnv("fail-on-duplicate", "GHUP_FAIL_ON_DUPLICATE")
+
+	contentCmd.Flags().Bool("skip-empty", false, "skip zero-byte additions")
+	viper.BindPFlag("skip-empty", contentCmd.Flags().Lookup("skip-empty"))
+	viper.BindEnv("skip-empty", "GHUP_SKIP_EMPTY")
+
+	contentCmd.Flags().Bool("fail-empty", false, "abort if any addition is zero-byte")
+	viper.BindPFlag("fail-empty", contentCmd.Flags().Lookup("fail-empty"))
+	viper.BindEnv("fail-empty", "GHUP_FAIL_EMPTY")
+
+	contentCmd.MarkFlagsMutuallyExclusive("skip-empty", "fail-empty")
+
+	contentCmd.Flags().Bool("split-by-dir", false, "commit changes to each top-level directory separately")
+	viper.BindPFlag("split-by-dir", contentCmd.Flags().Lookup("split-by-dir"))
+	viper.BindEnv("split-by-dir", "GHUP_SPLIT_BY_DIR")
+
+	contentCmd.Flags().String("split-message", "update {{.Dir}}", "commit message `template` for each --split-by-dir commit")
+	viper.BindPFlag("split-message", contentCmd.Flags().Lookup("split-message"))
+	viper.BindEnv("split-message", "GHUP_SPLIT_MESSAGE")
+
+	contentCmd.Flags().Bool("author-from-viewer", false, "commit as token owner via the Git Database API (unverified)")
+	viper.BindPFlag("author-from-viewer", contentCmd.Flags().Lookup("author-from-viewer"))
+	viper.BindEnv("author-from-viewer", "GHUP_AUTHOR_FROM_VIEWER")
+
+	contentCmd.Flags().String("committer-timezone", "", "IANA `timezone` name or fixed offset (e.g. +02:00) of commit dates, committing as token owner via the Git Database API (unverified; default UTC)")
+	viper.BindPFlag("committer-timezone", contentCmd.Flags().Lookup("committer-timezone"))
+	viper.BindEnv("committer-timezone", "GHUP_COMMITTER_TIMEZONE")
+
+	contentCmd.Flags().Bool("unlock-branch", false, "unlock target branch before committing (requires admin)")
+	viper.BindPFlag("unlock-branch", contentCmd.Flags().Lookup("unlock-branch"))
+	viper.BindEnv("unlock-branch", "GHUP_UNLOCK_BRANCH")
+
+	contentCmd.Flags().Bool("lock-branch-after-commit", false, "lock target branch after committing (requires admin)")
+	viper.BindPFlag("lock-branch-after-commit", contentCmd.Flags().Lookup("lock-branch-after-commit"))
+	viper.BindEnv("lock-branch-after-commit", "GHUP_LOCK_BRANCH_AFTER_COMMIT")
+
+	contentCmd.Flags().String("commit-comment", "", "post `text` as a comment on the new commit")
+	viper.BindPFlag("commit-comment", contentCmd.Flags().Lookup("commit-comment"))
+	viper.BindEnv("commit-comment", "GHUP_COMMIT_COMMENT")
+
+	contentCmd.Flags().String("commit-comment-file", "", "post the content of `file` as a comment on the new commit")
+	viper.BindPFlag("commit-comment-file", contentCmd.Flags().Lookup("commit-comment-file"))
+	viper.BindEnv("commit-comment-file", "GHUP_COMMIT_COMMENT_FILE")
+
+	contentCmd.MarkFlagsMutuallyExclusive("commit-comment", "commit-comment-file")
+	contentCmd.MarkFlagsMutuallyExclusive("tree", "split-by-dir")
+
+	contentCmd.Flags().Bool("commit-comment-strict", false, "abort if the commit comment cannot be posted")
+	viper.BindPFlag("commit-comment-strict", contentCmd.Flags().Lookup("commit-comment-strict"))
+	viper.BindEnv("commit-comment-strict", "GHUP_COMMIT_COMMENT_STRICT")
+
+	contentCmd.Flags().String("post-commit-exec", "", "run shell `command` after committing, with the result in its environment")
+	viper.BindPFlag("post-commit-exec", contentCmd.Flags().Lookup("post-commit-exec"))
+	viper.BindEnv("post-commit-exec", "GHUP_POST_COMMIT_EXEC")
+
+	contentCmd.Flags().Bool("post-commit-strict", false, "abort if the post-commit command fails")
+	viper.BindPFlag("post-commit-strict", contentCmd.Flags().Lookup("post-commit-strict"))
+	viper.BindEnv("post-commit-strict", "GHUP_POST_COMMIT_STRICT")
+
+	contentCmd.Flags().Bool("list-changes-only", false, "print planned changes as tab-separated action and path lines, without committing")
+	viper.BindPFlag("list-changes-only", contentCmd.Flags().Lookup("list-changes-only"))
+	viper.BindEnv("list-changes-only", "GHUP_LIST_CHANGES_ONLY")
+
+	contentCmd.Flags().Int("changes-exit-code", 0, "with --list-changes-only or --dry-run-base, exit `code` if there are changes")
+	viper.BindPFlag("changes-exit-code", contentCmd.Flags().Lookup("changes-exit-code"))
+	viper.BindEnv("changes-exit-code", "GHUP_CHANGES_EXIT_CODE")
+
+	contentCmd.Flags().String("dry-run-base", "", "list changes planned against `ref` rather than the target branch, without committing")
+	viper.BindPFlag("dry-run-base", contentCmd.Flags().Lookup("dry-run-base"))
+	viper.BindEnv("dry-run-base", "GHUP_DRY_RUN_BASE")
+
+	contentCmd.MarkFlagsMutuallyExclusive("tree", "list-changes-only")
+	contentCmd.MarkFlagsMutuallyExclusive("tree", "dry-run-base")
+
+	contentCmd.Flags().String("idempotency-key", "", "Idempotency-Key header `base` for mutating requests (default derived from target and planned changes)")
+	viper.BindPFlag("idempotency-key", contentCmd.Flags().Lookup("idempotency-key"))
+	viper.BindEnv("idempotency-key", "GHUP_IDEMPOTENCY_KEY")
+
+	contentCmd.Flags().String("report-file", "", "write a JSON audit record of the run to `path`")
+	viper.BindPFlag("report-file", contentCmd.Flags().Lookup("report-file"))
+	viper.BindEnv("report-file", "GHUP_REPORT_FILE")
+
+	contentCmd.Flags().Bool("report-unchanged-tip", false, "if there is nothing to do, output the unchanged target branch tip, marked as such")
+	viper.BindPFlag("report-unchanged-tip", contentCmd.Flags().Lookup("report-unchanged-tip"))
+	viper.BindEnv("report-unchanged-tip", "GHUP_REPORT_UNCHANGED_TIP")
+
+	contentCmd.Flags().Bool("print-urls", false, "also output the commit time, and the browse URLs of the target branch and of each addition")
+	viper.BindPFlag("print-urls", contentCmd.Flags().Lookup("print-urls"))
+	viper.BindEnv("print-urls", "GHUP_PRINT_URLS")
+
+	output := choiceflag.NewChoiceFlag([]string{"text", "json"})
+	_ = output.Set("text")
+	contentCmd.Flags().Var(output, "output", "output format")
+	viper.BindPFlag("output", contentCmd.Flags().Lookup("output"))
+	viper.BindEnv("output", "GHUP_OUTPUT")
+
 	contentCmd.Flags().SortFlags = false
+	contentCmd.PersistentFlags().SortFlags = false
 
 	rootCmd.AddCommand(contentCmd)
 }
 
+// normalizeTarget cleans a remote target path per configuration, logging any change
+func normalizeTarget(target string) (string, error) {
+	normalized, err := local.NormalizeTargetPath(target, viper.GetBool("windows-paths"))
+	if err != nil {
+		return "", err
+	}
+	if normalized != target {
+		log.Infof("normalized target %q to %q", target, normalized)
+	}
+	return normalized, nil
+}
+
+// seedRepository creates the first commit of an empty repository on the target branch via the
+// Contents API, consuming the first addition of changes, and reconciles the default branch with it
+func seedRepository(ctx context.Context, client *remote.TokenClient, changes *githubv4.FileChanges, result *contentResult) (oid githubv4.GitObjectID, err error) {
+	additions := *changes.Additions
+	if len(additions) == 0 {
+		return "", fmt.Errorf("cannot seed empty repository without additions")
+	}
+	seed := additions[0]
+	*changes.Additions = additions[1:]
+
+	content, err := base64.StdEncoding.DecodeString(string(seed.Contents))
+	if err != nil {
+		return "", err
+	}
+
+	// GitHub reports the configured default branch of an empty repository, although it is yet unborn
+	defaultBranch, err := client.GetDefaultBranchV3(ctx, owner, repo)
+	if err != nil {
+		return "", errors.Wrapf(err, "GetDefaultBranchV3(%s, %s)", owner, repo)
+	}
+
+	message = util.BuildCommitMessage()
+	sha, url, err := client.CreateFileV3(ctx, owner, repo, branch, string(seed.Path), content, message)
+	if err != nil {
+		return "", errors.Wrapf(err, "CreateFileV3(%s, %s, %s, %s)", owner, repo, branch, seed.Path)
+	}
+	log.Infof("seeded %q with %q", branch, seed.Path)
+	result.Commit, result.Url = sha, url
+
+	if defaultBranch != "" && defaultBranch != branch {
+		if viper.GetBool("update-default-branch") {
+			log.Infof("updating default branch from %q to %q", defaultBranch, branch)
+			if err := client.SetDefaultBranchV3(ctx, owner, repo, branch); err != nil {
+				return "", errors.Wrapf(err, "SetDefaultBranchV3(%s, %s, %s)", owner, repo, branch)
+			}
+		} else {
+			result.Warnings.Add("default-branch-mismatch", "", "seeded branch %q differs from configured default branch %q", branch, defaultBranch)
+		}
+	}
+
+	return githubv4.GitObjectID(sha), nil
+}
+
+// createRepository creates the target repository, under the organization or user
+// owner per --owner-type, auto-detecting owner type by default
+func createRepository(ctx context.Context, client *remote.TokenClient) (err error) {
+	ownerType := viper.GetString("owner-type")
+	if ownerType == "auto" {
+		switch ownerType, err = client.GetOwnerTypeV4(owner); {
+		case err != nil:
+			return errors.Wrapf(err, "GetOwnerTypeV4(%s)", owner)
+		case ownerType == "Organization":
+			ownerType = "org"
+		default:
+			ownerType = "user"
+		}
+	}
+
+	if ownerType == "user" {
+		login, err := client.GetViewerLoginV4()
+		if err != nil {
+			return errors.Wrap(err, "GetViewerLoginV4")
+		}
+		if !strings.EqualFold(login, owner) {
+			return fmt.Errorf("cannot create repository for user %q as %q", owner, login)
+		}
+	}
+
+	template := viper.GetString("template-repo")
+	if template == "" {
+		log.Infof("creating repository %s/%s (%s)", owner, repo, ownerType)
+		url, err := client.CreateRepositoryV3(ctx, owner, repo, ownerType == "org")
+		if err != nil {
+			return errors.Wrapf(err, "CreateRepositoryV3(%s, %s)", owner, repo)
+		}
+		log.Infof("created repository %s", url)
+		return nil
+	}
+
+	templateOwner, templateRepo, found := strings.Cut(template, "/")
+	if !found || templateOwner == "" || templateRepo == "" {
+		return fmt.Errorf("invalid template repository %q: expected owner/repo", template)
+	}
+	log.Infof("creating repository %s/%s (%s) from template %s", owner, repo, ownerType, template)
+	url, err := client.CreateRepositoryFromTemplateV3(ctx, templateOwner, templateRepo, owner, repo)
+	if err != nil {
+		return errors.Wrapf(err, "CreateRepositoryFromTemplateV3(%s, %s, %s, %s)", templateOwner, templateRepo, owner, repo)
+	}
+	log.Infof("created repository %s", url)
+
+	// wait for GitHub to populate the repository from the template
+	for attempt := 0; attempt < templateWaitAttempts; attempt++ {
+		repoInfo, err := client.GetRepositoryInfo(owner, repo, branch)
+		if err == nil && !repoInfo.IsEmpty {
+			return nil
+		}
+		time.Sleep(time.Second)
+	}
+	return fmt.Errorf("timed out waiting for repository %s/%s to be populated from template %s", owner, repo, template)
+}
+
+const templateWaitAttempts = 30
+
+// pruneDeletions drops planned deletions of paths no longer present at commit oid
+// (e.g. deleted by syncing the target branch with its base)
+func pruneDeletions(client *remote.TokenClient, oid githubv4.GitObjectID, changes *githubv4.FileChanges, result *contentResult) {
+	deletions := []githubv4.FileDeletion{}
+	for _, deletion := range *changes.Deletions {
+		if client.GetFileHashV4(owner, repo, string(oid), string(deletion.Path)) == "" {
+			log.Infof("%q absent after sync: skipping deletion", deletion.Path)
+			continue
+		}
+		deletions = append(deletions, deletion)
+	}
+	*changes.Deletions = deletions
+	result.Deletions = slices.DeleteFunc(result.Deletions, func(deletion fileChange) bool {
+		return !slices.Contains(deletions, githubv4.FileDeletion{Path: githubv4.String(deletion.Path)})
+	})
+}
+
+// uploadLFSObjects uploads the Git LFS objects referenced by planned additions
+func uploadLFSObjects(ctx context.Context, client *remote.TokenClient, additions []fileChange) error {
+	for _, addition := range additions {
+		if addition.lfs == nil {
+			continue
+		}
+		if err := client.UploadLFSObject(ctx, owner, repo, addition.lfs.Oid, addition.lfs.Size, addition.lfs.content); err != nil {
+			return errors.Wrapf(err, "UploadLFSObject(%s, %s, %s)", owner, repo, addition.lfs.Oid)
+		}
+	}
+	return nil
+}
+
+// commitContent commits changes on top of parent, via the Git Database API if an explicit author
+// is required, or if modes sets the mode of any addition (by path), which the V4 API cannot
+func commitContent(ctx context.Context, client *remote.TokenClient, parent githubv4.GitObjectID, changes githubv4.FileChanges, modes map[string]string, message string) (oid string, url string, err error) {
+	var identity *github.CommitAuthor
+	// as with commitTree, a commit date can only be stamped along with an explicit identity
+	if viper.GetBool("author-from-viewer") || viper.GetString("committer-timezone") != "" {
+		identity, err = client.GetViewerIdentityV4()
+		if err != nil {
+			return "", "", errors.Wrap(err, "GetViewerIdentityV4")
+		}
+		log.Infof("committing as %s <%s>", identity.GetName(), identity.GetEmail())
+		if err := stampIdentity(identity); err != nil {
+			return "", "", err
+		}
+	}
+	if identity != nil || len(modes) > 0 {
+		oid, url, err = client.CreateCommitOnBranchV3(ctx, owner, repo, branch, string(parent), changes, modes, message, identity, identity)
+		if err != nil {
+			return "", "", errors.Wrap(err, "CreateCommitOnBranchV3")
+		}
+		return oid, url, nil
+	}
+
+	input := githubv4.CreateCommitOnBranchInput{
+		Branch:          remote.CommittableBranch(owner, repo, branch),
+		Message:         remote.CommitMessage(message),
+		ExpectedHeadOid: parent,
+		FileChanges:     &changes,
+	}
+	log.Debugf("CreateCommitOnBranchInput: %+v", input)
+
+	commitOid, url, err := client.CreateCommitOnBranchV4(input)
+	if err != nil {
+		return "", "", errors.Wrap(err, "CommitOnBranchV4")
+	}
+	return string(commitOid), url, nil
+}
+
+// commitContentByDir commits changes as a chain of commits on top of parent, one per
+// top-level directory, recording each and setting the result to the last
+func commitContentByDir(ctx context.Context, client *remote.TokenClient, parent githubv4.GitObjectID, changes githubv4.FileChanges, result *contentResult) error {
+	tmpl, err := template.New("split-message").Parse(viper.GetString("split-message"))
+	if err != nil {
+		return errors.Wrap(err, "split-message")
+	}
+
+	dirs, groups := splitChangesByDir(changes)
+	for _, dir := range dirs {
+		var title strings.Builder
+		if err := tmpl.Execute(&title, struct{ Dir string }{Dir: dir}); err != nil {
+			return errors.Wrapf(err, "split-message(%s)", dir)
+		}
+		message = util.ComposeCommitMessage(title.String())
+
+		log.Infof("committing changes to %q", dir)
+		oid, url, err := commitContent(ctx, client, parent, groups[dir], nil, message)
+		if err != nil {
+			return err
+		}
+		parent = githubv4.GitObjectID(oid)
+		result.Commit, result.Url = oid, url
+		result.Commits = append(result.Commits, oid)
+	}
+	return nil
+}
+
+// runPostCommitExec runs any configured post-commit command via the shell, with the result
+// in its environment, failing only if --post-commit-strict is set
+func runPostCommitExec(result *contentResult) error {
+	command := viper.GetString("post-commit-exec")
+	if command == "" {
+		return nil
+	}
+
+	var hook *exec.Cmd
+	if runtime.GOOS == "windows" {
+		hook = exec.Command("cmd", "/C", command)
+	} else {
+		hook = exec.Command("sh", "-c", command)
+	}
+	hook.Env = append(os.Environ(),
+		"GHUP_OWNER="+owner,
+		"GHUP_REPO="+repo,
+		"GHUP_BRANCH="+branch,
+		"GHUP_COMMIT_SHA="+result.Commit,
+		"GHUP_COMMIT_URL="+result.Url,
+		"GHUP_PULL_REQUEST_URL="+result.PullRequest,
+	)
+	// keep stdout for the result
+	hook.Stdout = os.Stderr
+	hook.Stderr = os.Stderr
+
+	log.Infof("running post-commit command %q", command)
+	err := hook.Run()
+	switch {
+	case err != nil && viper.GetBool("post-commit-strict"):
+		return errors.Wrapf(err, "post-commit command %q", command)
+	case err != nil:
+		result.Warnings.Add("post-commit-exec-failed", "", "post-commit command %q: %s", command, err)
+	}
+	return nil
+}
+
+// checkOpenPullRequests guards against runaway automation, refusing to open more than
+// --max-open-prs pull requests authored by the token owner, unless forced
+func checkOpenPullRequests(client *remote.TokenClient) error {
+	limit := viper.GetInt("max-open-prs")
+	if limit <= 0 {
+		return nil
+	}
+	count, err := client.CountOpenPullRequestsV4(owner, repo)
+	if err != nil {
+		return errors.Wrapf(err, "CountOpenPullRequestsV4(%s, %s)", owner, repo)
+	}
+	log.Infof("%d open pull requests by token owner", count)
+	if count >= limit {
+		if !force {
+			return fmt.Errorf("token owner already has %d open pull requests in %s/%s (limit %d)", count, owner, repo, limit)
+		}
+		log.Warnf("token owner already has %d open pull requests in %s/%s (limit %d)", count, owner, repo, limit)
+	}
+	return nil
+}
+
+// commentCommit posts any configured commit comment on sha, failing only if
+// --commit-comment-strict is set
+func commentCommit(ctx context.Context, client *remote.TokenClient, sha string, result *contentResult) error {
+	body := viper.GetString("commit-comment")
+	if file := viper.GetString("commit-comment-file"); file != "" {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return errors.Wrapf(err, "ReadFile(%s)", file)
+		}
+		body = string(content)
+	}
+	if body == "" {
+		return nil
+	}
+
+	url, err := client.CreateCommitCommentV3(ctx, owner, repo, sha, body)
+	switch {
+	case err != nil && viper.GetBool("commit-comment-strict"):
+		return errors.Wrapf(err, "CreateCommitCommentV3(%s, %s, %s)", owner, repo, sha)
+	case err != nil:
+		result.Warnings.Add("commit-comment-failed", "", "posting commit comment on %s: %s", sha, err)
+	default:
+		log.Infof("commit comment posted: %s", url)
+	}
+	return nil
+}
+
+// stampIdentity dates identity now in the configured --committer-timezone, if any
+func stampIdentity(identity *github.CommitAuthor) error {
+	timezone := viper.GetString("committer-timezone")
+	if timezone == "" {
+		return nil
+	}
+	location, err := util.ParseTimezone(timezone)
+	if err != nil {
+		return err
+	}
+	identity.Date = &github.Timestamp{Time: time.Now().In(location)}
+	return nil
+}
+
+// commitTree commits the existing tree treeSHA on top of parent via the Git Database API
+func commitTree(ctx context.Context, client *remote.TokenClient, parent githubv4.GitObjectID, treeSHA string, message string) (oid string, url string, err error) {
+	var identity *github.CommitAuthor
+	// a commit date can only be stamped along with an explicit identity
+	if viper.GetBool("author-from-viewer") || viper.GetString("committer-timezone") != "" {
+		identity, err = client.GetViewerIdentityV4()
+		if err != nil {
+			return "", "", errors.Wrap(err, "GetViewerIdentityV4")
+		}
+		log.Infof("committing as %s <%s>", identity.GetName(), identity.GetEmail())
+		if err := stampIdentity(identity); err != nil {
+			return "", "", err
+		}
+	}
+
+	log.Infof("committing tree %s", treeSHA)
+	oid, url, err = client.CreateTreeCommitOnBranchV3(ctx, owner, repo, branch, string(parent), treeSHA, message, identity, identity)
+	if err != nil {
+		return "", "", errors.Wrap(err, "CreateTreeCommitOnBranchV3")
+	}
+	return oid, url, nil
+}
+
 func runContentCmd(cmd *cobra.Command, args []string) (err error) {
 	ctx := context.Background()
 
-	client, err := remote.NewTokenClient(ctx, viper.GetString("token"))
+	result := contentResult{
+		Additions: []fileChange{},
+		Deletions: []fileChange{},
+		Warnings:  contentWarnings{},
+	}
+
+	var report *contentReport
+	if reportFile := viper.GetString("report-file"); reportFile != "" {
+		report = newContentReport(args, &result)
+		defer func() {
+			if reportErr := report.Write(reportFile, err); reportErr != nil {
+				log.Errorf("writing report %q: %s", reportFile, reportErr)
+			}
+		}()
+	}
+
+	client, err := remote.NewTokenClient(ctx, viper.GetString("token"), clientOptions()...)
 	if err != nil {
 		return errors.Wrap(err, "NewTokenClient")
 	}
+	if report != nil {
+		report.client = client
+	}
 
 	separator := viper.GetString("separator")
 	if len(separator) < 1 {
 		return fmt.Errorf("invalid separator")
 	}
 
+	if timezone := viper.GetString("committer-timezone"); timezone != "" {
+		if _, err := util.ParseTimezone(timezone); err != nil {
+			return err
+		}
+	}
+
+	treeSHA := viper.GetString("tree")
+	if treeSHA != "" && (len(args) > 0 || len(viper.GetStringSlice("update")) > 0 || len(viper.GetStringSlice("delete")) > 0 || viper.GetString("delete-missing-from") != "" || viper.GetBool("from-status")) {
+		return fmt.Errorf("--tree cannot be combined with file-specs, --update, --delete, --delete-missing-from or --from-status")
+	}
+
+	createBranch := viper.GetBool("create-branch")
+	if number := viper.GetInt("resolve-branch-from-pr"); number > 0 {
+		head, err := client.GetPullRequestHeadV4(owner, repo, number)
+		if err != nil {
+			return errors.Wrapf(err, "GetPullRequestHeadV4(%s, %s, %d)", owner, repo, number)
+		}
+		if head.State != githubv4.PullRequestStateOpen && !force {
+			return fmt.Errorf("pull request #%d is %s", number, strings.ToLower(string(head.State)))
+		}
+		log.Infof("resolved pull request #%d head to %s/%s:%s", number, head.Owner, head.Repo, head.Branch)
+		owner, repo, branch = head.Owner, head.Repo, head.Branch
+		// the head branch of a pull request must already exist
+		createBranch = false
+	}
+
+	// planning changes without committing them must not change anything remotely
+	dryRunBase := viper.GetString("dry-run-base")
+	planOnly := viper.GetBool("list-changes-only") || dryRunBase != ""
+
 	repoInfo, err := client.GetRepositoryInfo(owner, repo, branch)
-	if err != nil {
+	if errors.Is(err, remote.ErrRepositoryNotFound) && viper.GetBool("create-repo") && !planOnly {
+		if err := createRepository(ctx, client); err != nil {
+			return err
+		}
+		repoInfo, err = client.GetRepositoryInfo(owner, repo, branch)
+	}
+	switch {
+	case errors.Is(err, remote.ErrRepositoryArchived):
+		// planning without committing remains possible
+		if !planOnly {
+			return err
+		}
+	case err != nil:
 		return errors.Wrapf(err, "GetRepositoryInfo(%s, %s, %s)", owner, repo, branch)
 	}
 
 	if repoInfo.IsEmpty {
-		return fmt.Errorf("cannot push to empty repository")
+		switch {
+		case !createBranch:
+			return fmt.Errorf("target branch %q does not exist in empty repository", branch)
+		case treeSHA != "":
+			return fmt.Errorf("cannot commit --tree to empty repository")
+		case viper.GetString("since-commit") != "":
+			return fmt.Errorf("cannot use --since-commit on empty repository")
+		}
+		log.Infof("seeding empty repository on branch %q", branch)
+	}
+
+	if repoInfo.TargetBranch.RequiresCommitSignatures && viper.GetBool("author-from-viewer") {
+		return fmt.Errorf("target branch %q requires signed commits, but --author-from-viewer commits are unsigned: drop it to commit via the V4 API, which signs commits", branch)
+	}
+
+	if repoInfo.TargetBranch.RequiresCommitSignatures && viper.GetString("committer-timezone") != "" {
+		return fmt.Errorf("target branch %q requires signed commits, but --committer-timezone commits are unsigned: drop it to commit via the V4 API, which signs commits", branch)
+	}
+
+	if repoInfo.TargetBranch.RequiresCommitSignatures && treeSHA != "" {
+		return fmt.Errorf("target branch %q requires signed commits, but --tree commits are unsigned", branch)
+	}
+
+	unlockBranch := viper.GetBool("unlock-branch")
+	lockBranch := viper.GetBool("lock-branch-after-commit")
+	if (unlockBranch || lockBranch) && repoInfo.ViewerPermission != "" && repoInfo.ViewerPermission != githubv4.RepositoryPermissionAdmin {
+		return fmt.Errorf("branch locking requires admin permission on %s/%s (have %s)", owner, repo, repoInfo.ViewerPermission)
 	}
 
 	targetOid := repoInfo.TargetBranch.Commit
 	baseBranch := viper.GetString("base-branch")
 	newBranch := false
 
-	if targetOid == "" {
-		if !viper.GetBool("create-branch") {
+	if expected := strings.ToLower(viper.GetString("expected-branch-head")); expected != "" {
+		switch {
+		case len(expected) < 7:
+			return fmt.Errorf("invalid expected branch head %q: at least 7 hex digits required", expected)
+		case targetOid == "":
+			return fmt.Errorf("target branch %q does not exist: expected head %s", branch, expected)
+		case !strings.HasPrefix(string(targetOid), expected):
+			return fmt.Errorf("target branch %q moved: expected head %s, actual %s", branch, expected, targetOid)
+		}
+	}
+
+	switch {
+	case dryRunBase != "":
+		sha, _, err := client.GetCommitSHA(ctx, owner, repo, dryRunBase)
+		if err != nil {
+			return errors.Wrapf(err, "GetCommitSHA(%s, %s, %s)", owner, repo, dryRunBase)
+		}
+		log.Infof("planning against %q (%s)", dryRunBase, *sha)
+		targetOid = githubv4.GitObjectID(*sha)
+	case targetOid == "" && !repoInfo.IsEmpty:
+		if !createBranch {
 			return fmt.Errorf("target branch %q does not exist", branch)
 		}
-		log.Infof("creating target branch %q", branch)
-		if baseBranch == "" {
-			baseBranch = repoInfo.DefaultBranch.Name
+		if baseBranch, err = util.ResolveBaseBranch(viper.GetString("base-preference"), baseBranch, repoInfo.DefaultBranch.Name); err != nil {
+			return err
+		}
+		if baseBranch == repoInfo.DefaultBranch.Name {
 			targetOid = repoInfo.DefaultBranch.Commit
-			log.Infof("defaulting base branch to %q", baseBranch)
+			log.Infof("using default branch %q as base", baseBranch)
 		} else {
 			targetOid, err = client.GetRefOidV4(owner, repo, baseBranch)
 			if err != nil {
 				return errors.Wrapf(err, "GetRefOidV4(%s, %s, %s)", owner, repo, baseBranch)
 			}
 		}
+		newBranch = true
+	}
 
+	// plan against the base of any new branch, so as not to leave an empty branch behind
+	var changes githubv4.FileChanges
+	if treeSHA == "" {
+		additions, deletions, err := planContent(ctx, client, targetOid, args, separator, &result)
+		if err != nil {
+			return err
+		}
+		log.Debugf("Additions: %+v", additions)
+		log.Debugf("Deletions: %+v", deletions)
+		changes = githubv4.FileChanges{
+			Additions: &additions,
+			Deletions: &deletions,
+		}
+	}
+	emptyPlan := treeSHA == "" && len(*changes.Additions) == 0 && len(*changes.Deletions) == 0
+
+	if planOnly {
+		if viper.GetString("output") == "json" {
+			plan, err := json.Marshal(contentPlan{Base: string(targetOid), Additions: result.Additions, Deletions: result.Deletions})
+			if err != nil {
+				return errors.Wrap(err, "json.Marshal")
+			}
+			fmt.Println(string(plan))
+		} else {
+			for _, addition := range result.Additions {
+				fmt.Printf("update\t%s\n", addition.Path)
+			}
+			for _, deletion := range result.Deletions {
+				fmt.Printf("delete\t%s\n", deletion.Path)
+			}
+		}
+		if code := viper.GetInt("changes-exit-code"); code != 0 && !emptyPlan {
+			cmd.SilenceErrors = true
+			return &exitCodeError{code: code}
+		}
+		return nil
+	}
+
+	idempotencyKey := viper.GetString("idempotency-key")
+	if idempotencyKey == "" {
+		idempotencyKey = planIdempotencyKey(treeSHA, &result)
+	}
+	log.Debugf("idempotency key: %s", idempotencyKey)
+	client.SetIdempotencyKey(idempotencyKey)
+
+	// check before creating anything the pull request would be opened for
+	if newBranch && !emptyPlan && viper.GetString("pr-title") != "" {
+		if err := checkOpenPullRequests(client); err != nil {
+			return err
+		}
+	}
+
+	if err := uploadLFSObjects(ctx, client, result.Additions); err != nil {
+		return err
+	}
+
+	if newBranch && (!emptyPlan || viper.GetBool("create-branch-always")) {
+		log.Infof("creating target branch %q", branch)
 		createRefInput := githubv4.CreateRefInput{
 			RepositoryID: repoInfo.NodeID,
 			Name:         githubv4.String(fmt.Sprintf("refs/heads/%s", branch)),
@@ -110,71 +951,100 @@ func runContentCmd(cmd *cobra.Command, args []string) (err error) {
 		if err := client.CreateRefV4(createRefInput); err != nil {
 			return errors.Wrap(err, "CreateRefV4")
 		}
-		newBranch = true
 	}
 
-	updateFiles := append(args, viper.GetStringSlice("update")...)
-	deleteFiles := viper.GetStringSlice("delete")
+	// the tip of a new branch is only that of the target branch if it was created
+	branchCreated := newBranch && viper.GetBool("create-branch-always")
+	if emptyPlan && viper.GetBool("report-unchanged-tip") && targetOid != "" && (!newBranch || branchCreated) {
+		log.Infof("nothing to do: reporting unchanged tip %s", targetOid)
+		result.Commit = string(targetOid)
+		if result.Url, err = client.GetCommitUrlV4(owner, repo, result.Commit); err != nil {
+			return errors.Wrapf(err, "GetCommitUrlV4(%s, %s, %s)", owner, repo, result.Commit)
+		}
+		result.Unchanged = true
+		printContentResult(result)
+		return nil
+	}
 
-	additions := []githubv4.FileAddition{}
-	deletions := []githubv4.FileDeletion{}
+	if emptyPlan {
+		result.Warnings.Add("nothing-to-do", "", "nothing to do")
+		printContentResult(result)
+		return nil
+	}
 
-	for _, arg := range updateFiles {
-		target, content, err := local.GetLocalFileContent(arg, separator)
-		if err != nil {
-			return errors.Wrapf(err, "GetLocalFileContent(%s, %s)", arg, separator)
-		}
-		local_hash := plumbing.ComputeHash(plumbing.BlobObject, content).String()
-		remote_hash := client.GetFileHashV4(owner, repo, branch, target)
-		log.Infof("local: %s, remote: %s", local_hash, remote_hash)
-		if local_hash != remote_hash || force {
-			log.Infof("%q queued for addition", target)
-			additions = append(additions, githubv4.FileAddition{
-				Path:     githubv4.String(target),
-				Contents: githubv4.Base64String(base64.StdEncoding.EncodeToString(content)),
-			})
-		} else {
-			log.Infof("%q (%s) on target branch: skipping addition", target, remote_hash)
+	// unlock only to commit, restoring the lock if the run then fails or commits nothing
+	committed := false
+	if unlockBranch && repoInfo.TargetBranch.Locked {
+		log.Infof("unlocking target branch %q", branch)
+		if err := client.SetBranchLock(ctx, owner, repo, branch, false); err != nil {
+			return errors.Wrapf(err, "SetBranchLock(%s, %s, %s, false)", owner, repo, branch)
 		}
+		defer func() {
+			if committed && err == nil {
+				return
+			}
+			log.Infof("restoring lock of target branch %q", branch)
+			if lockErr := client.SetBranchLock(ctx, owner, repo, branch, true); lockErr != nil {
+				log.Errorf("restoring lock of target branch %q: %s", branch, lockErr)
+			}
+		}()
 	}
 
-	for _, target := range deleteFiles {
-		remote_hash := client.GetFileHashV4(owner, repo, branch, target)
-		if remote_hash != "" || force {
-			log.Infof("%q queued for deletion", target)
-			deletions = append(deletions, githubv4.FileDeletion{
-				Path: githubv4.String(target),
-			})
-		} else {
-			log.Infof("%q absent on target branch: skipping deletion", target)
+	// sync only once the run is known to commit, its changes having been planned and checked;
+	// a new branch is created from its base, so is already in sync
+	if syncBase := viper.GetString("sync-with-base"); syncBase != "" && targetOid != "" && !newBranch {
+		oid, err := client.SyncBranchV3(ctx, owner, repo, branch, syncBase)
+		switch {
+		case errors.Is(err, remote.ErrMergeConflict):
+			return fmt.Errorf("cannot sync target branch %q with %q: merge conflict, resolve it manually", branch, syncBase)
+		case err != nil:
+			return errors.Wrapf(err, "SyncBranchV3(%s, %s, %s, %s)", owner, repo, branch, syncBase)
+		}
+		if synced := githubv4.GitObjectID(oid); synced != targetOid && treeSHA == "" && !force {
+			pruneDeletions(client, synced, &changes, &result)
 		}
+		targetOid = githubv4.GitObjectID(oid)
 	}
 
-	if len(additions) == 0 && len(deletions) == 0 {
-		log.Warn("nothing to do")
-		return nil
+	if repoInfo.IsEmpty {
+		if targetOid, err = seedRepository(ctx, client, &changes, &result); err != nil {
+			return err
+		}
 	}
 
-	changes := githubv4.FileChanges{
-		Additions: &additions,
-		Deletions: &deletions,
+	switch {
+	case repoInfo.IsEmpty && len(*changes.Additions) == 0 && len(*changes.Deletions) == 0:
+		// the seed commit was all there was to commit
+	case treeSHA != "":
+		message = util.BuildCommitMessage()
+		result.Commit, result.Url, err = commitTree(ctx, client, targetOid, treeSHA, message)
+	case viper.GetBool("split-by-dir"):
+		err = commitContentByDir(ctx, client, targetOid, changes, &result)
+	default:
+		message = util.BuildCommitMessage()
+		result.Commit, result.Url, err = commitContent(ctx, client, targetOid, changes, nil, message)
+	}
+	if err != nil {
+		return err
 	}
-	log.Debugf("Additions: %+v", additions)
-	log.Debugf("Deletions: %+v", deletions)
+	committed = true
 
-	message = util.BuildCommitMessage()
+	if committedAt, err := client.GetCommittedDateV4(owner, repo, result.Commit); err != nil {
+		result.Warnings.Add("committed-date-unknown", "", "querying committed date of %s: %s", result.Commit, err)
+	} else {
+		result.CommittedAt = committedAt.UTC().Format(time.RFC3339)
+		log.Infof("%s committed at %s", result.Commit, result.CommittedAt)
+	}
 
-	input := githubv4.CreateCommitOnBranchInput{
-		Branch:          remote.CommittableBranch(owner, repo, branch),
-		Message:         remote.CommitMessage(message),
-		ExpectedHeadOid: targetOid,
-		FileChanges:     &changes,
+	if err := commentCommit(ctx, client, result.Commit, &result); err != nil {
+		return err
 	}
-	log.Debugf("CreateCommitOnBranchInput: %+v", input)
 
-	_, commitUrl, err := client.CreateCommitOnBranchV4(input)
-	if err != nil {
-		return errors.Wrap(err, "CommitOnBranchV4")
+	if lockBranch {
+		log.Infof("locking target branch %q", branch)
+		if err := client.SetBranchLock(ctx, owner, repo, branch, true); err != nil {
+			return errors.Wrapf(err, "SetBranchLock(%s, %s, %s, true)", owner, repo, branch)
+		}
 	}
 
 	if title := viper.GetString("pr-title"); newBranch && title != "" {
@@ -193,9 +1063,17 @@ func runContentCmd(cmd *cobra.Command, args []string) (err error) {
 		if err != nil {
 			return errors.Wrap(err, "CreatePullRequestV4")
 		}
-		fmt.Println(pullRequestUrl)
-	} else {
-		fmt.Println(commitUrl)
+		result.PullRequest = pullRequestUrl
 	}
+
+	if viper.GetBool("print-urls") {
+		addBrowseUrls(&result)
+	}
+
+	if err := runPostCommitExec(&result); err != nil {
+		return err
+	}
+
+	printContentResult(result)
 	return
 }
@@ -0,0 +1,455 @@
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/pkg/errors"
+	"github.com/shurcooL/githubv4"
+	"github.com/spf13/viper"
+
+	"github.com/nexthink-oss/ghup/internal/local"
+	"github.com/nexthink-oss/ghup/internal/remote"
+)
+
+// statusFileSpecs maps the changes reported by git status on the local work tree
+// to file-specs (and, if requested, deletions) of the same paths on the target
+func statusFileSpecs(separator string) (updates []string, deletions []string, err error) {
+	if localRepo == nil {
+		return nil, nil, fmt.Errorf("--from-status requires a local repository")
+	}
+	root, err := localRepo.Root()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Root")
+	}
+	status, err := localRepo.Status()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "Status")
+	}
+
+	changed, deleted := local.StatusChanges(status)
+	for _, target := range changed {
+		updates = append(updates, filepath.Join(root, filepath.FromSlash(target))+separator+target)
+	}
+	log.Infof("%d paths modified or added per git status", len(changed))
+	if viper.GetBool("include-deletions") {
+		deletions = deleted
+	} else if len(deleted) > 0 {
+		log.Infof("ignoring %d paths deleted per git status", len(deleted))
+	}
+	return
+}
+
+// checkCleanWorktree refuses to push an inconsistent snapshot, should the local work tree
+// have untracked or unstaged changes other than the sources of updateFiles and statusDeletions
+func checkCleanWorktree(updateFiles []string, statusDeletions []string, separator string, contentRoot string) error {
+	if localRepo == nil {
+		return fmt.Errorf("--require-clean-worktree requires a local repository")
+	}
+	root, err := localRepo.Root()
+	if err != nil {
+		return errors.Wrap(err, "Root")
+	}
+	status, err := localRepo.Status()
+	if err != nil {
+		return errors.Wrap(err, "Status")
+	}
+
+	pushed := map[string]bool{}
+	for _, deletion := range statusDeletions {
+		pushed[deletion] = true
+	}
+	for _, arg := range updateFiles {
+		source, _, err := local.ParseFileSpec(arg, separator)
+		if err != nil {
+			return errors.Wrapf(err, "ParseFileSpec(%s, %s)", arg, separator)
+		}
+		if rel, ok := worktreePath(root, source, contentRoot); ok {
+			pushed[rel] = true
+		}
+	}
+
+	if unpushed := local.UnpushedChanges(status, pushed); len(unpushed) > 0 {
+		return fmt.Errorf("work tree has %d untracked or unstaged changes not being pushed, including %q", len(unpushed), unpushed[0])
+	}
+	return nil
+}
+
+// worktreePath returns the slash-separated path of local source relative to work tree root,
+// resolving a relative source against contentRoot, if set, and reports whether it is inside it
+func worktreePath(root string, source string, contentRoot string) (rel string, ok bool) {
+	source, err := filepath.Abs(local.ResolveSource(source, contentRoot))
+	if err != nil {
+		return "", false
+	}
+	rel, err = filepath.Rel(root, source)
+	if err != nil || !filepath.IsLocal(rel) {
+		return "", false
+	}
+	return filepath.ToSlash(rel), true
+}
+
+// ignoredSources returns a func reporting whether explicitly named local sources are
+// ignored by the rules of the local work tree, if any
+func ignoredSources(contentRoot string) (ignored func(source string) bool, err error) {
+	if localRepo == nil {
+		log.Warn("--respect-gitignore without a local repository: no ignore rules apply")
+		return func(string) bool { return false }, nil
+	}
+	root, err := localRepo.Root()
+	if err != nil {
+		return nil, errors.Wrap(err, "Root")
+	}
+	matcher, err := localRepo.IgnoreMatcher()
+	if err != nil {
+		return nil, errors.Wrap(err, "IgnoreMatcher")
+	}
+	return func(source string) bool {
+		rel, ok := worktreePath(root, source, contentRoot)
+		return ok && matcher.Match(strings.Split(rel, "/"), false)
+	}, nil
+}
+
+// validateAddition checks that content parses as YAML or JSON where so configured,
+// by target extension unless --validate-all is set, exempting binary content
+func validateAddition(target string, content []byte) error {
+	validateAll := viper.GetBool("validate-all")
+	ext := strings.ToLower(path.Ext(target))
+	if local.IsBinary(content) {
+		return nil
+	}
+	if viper.GetBool("validate-yaml") && (validateAll || ext == ".yaml" || ext == ".yml") {
+		if err := local.ValidateYAML(content); err != nil {
+			return err
+		}
+	}
+	if viper.GetBool("validate-json") && (validateAll || ext == ".json") {
+		if err := local.ValidateJSON(content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// duplicatePath returns a path other than target holding blob hash, if any
+func duplicatePath(treePaths map[string][]string, hash string, target string) string {
+	for _, p := range treePaths[hash] {
+		if p != target {
+			return p
+		}
+	}
+	return ""
+}
+
+// planContent computes the additions and deletions needed to apply the configured
+// file-specs, deletions and manifest to the tree of commit targetOid
+func planContent(ctx context.Context, client *remote.TokenClient, targetOid githubv4.GitObjectID, args []string, separator string, result *contentResult) (additions []githubv4.FileAddition, deletions []githubv4.FileDeletion, err error) {
+	updateFiles := append(args, viper.GetStringSlice("update")...)
+	deleteFiles := viper.GetStringSlice("delete")
+	var statusDeletions []string
+	if viper.GetBool("from-status") {
+		var statusUpdates []string
+		statusUpdates, statusDeletions, err = statusFileSpecs(separator)
+		if err != nil {
+			return nil, nil, err
+		}
+		updateFiles = append(updateFiles, statusUpdates...)
+		deleteFiles = append(deleteFiles, statusDeletions...)
+	}
+
+	contentRoot := viper.GetString("content-root")
+	if viper.GetBool("require-clean-worktree") {
+		if err := checkCleanWorktree(updateFiles, statusDeletions, separator, contentRoot); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	additions = []githubv4.FileAddition{}
+	deletions = []githubv4.FileDeletion{}
+	updateTargets := map[string]bool{}
+
+	// map blob hashes to the paths holding them, to detect duplicate content
+	// (an empty repository has no tree to look up)
+	var treePaths map[string][]string
+	if (viper.GetBool("check-duplicates") || viper.GetBool("fail-on-duplicate")) && targetOid != "" {
+		entries, err := client.ListTreeV4(owner, repo, string(targetOid), "")
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "ListTreeV4(%s, %s, %s, )", owner, repo, targetOid)
+		}
+		treePaths = make(map[string][]string, len(entries))
+		for _, entry := range entries {
+			treePaths[entry.Oid] = append(treePaths[entry.Oid], entry.Path)
+		}
+	}
+
+	// hash local content with the object format of the target repository
+	sha256Format := local.IsSHA256(string(targetOid))
+	if sha256Format {
+		log.Info("target repository uses the SHA-256 object format")
+	}
+
+	forcePaths := viper.GetStringSlice("force-path")
+	for _, pattern := range forcePaths {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, nil, errors.Wrapf(err, "force-path %q", pattern)
+		}
+	}
+
+	scanSecrets := viper.GetBool("scan-secrets")
+	var secretsAllowlist []*regexp.Regexp
+	if allowlistFile := viper.GetString("secrets-allowlist"); scanSecrets && allowlistFile != "" {
+		allowlistContent, err := os.ReadFile(allowlistFile)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "secrets-allowlist")
+		}
+		if secretsAllowlist, err = local.ParseSecretsAllowlist(allowlistContent); err != nil {
+			return nil, nil, errors.Wrapf(err, "secrets-allowlist %q", allowlistFile)
+		}
+	}
+
+	var changedFiles map[string]bool
+	if sinceCommit := viper.GetString("since-commit"); sinceCommit != "" {
+		changedFiles, err = client.GetChangedFilesV3(ctx, owner, repo, sinceCommit, string(targetOid))
+		switch {
+		case errors.Is(err, remote.ErrTooManyChangedFiles):
+			result.Warnings.Add("since-commit-ignored", "", "too many files changed since %s: considering all updates", sinceCommit)
+		case err != nil:
+			return nil, nil, errors.Wrapf(err, "GetChangedFilesV3(%s, %s, %s, %s)", owner, repo, sinceCommit, targetOid)
+		default:
+			log.Infof("%d files changed since %s", len(changedFiles), sinceCommit)
+		}
+	}
+
+	var lfsMatcher *local.LFSMatcher
+	if viper.GetBool("lfs") {
+		var attributes []byte
+		if client.GetFileHashV4(owner, repo, string(targetOid), ".gitattributes") != "" {
+			attributes, err = client.GetFileContentV4(owner, repo, string(targetOid), ".gitattributes")
+			if err != nil {
+				return nil, nil, errors.Wrapf(err, "GetFileContentV4(%s, %s, %s, .gitattributes)", owner, repo, targetOid)
+			}
+		}
+		lfsMatcher, err = local.NewLFSMatcher(attributes, viper.GetStringSlice("lfs-pattern"))
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "NewLFSMatcher")
+		}
+	}
+
+	// file-specs name their sources explicitly, so always win over ignore rules, with a warning
+	isIgnored := func(string) bool { return false }
+	if viper.GetBool("respect-gitignore") {
+		if isIgnored, err = ignoredSources(contentRoot); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// buffer stdin and URL sources upfront, reading them in file-spec order
+	sources := &local.Sources{
+		Root:        contentRoot,
+		Stdin:       os.Stdin,
+		Client:      &http.Client{Timeout: viper.GetDuration("fetch-timeout")},
+		MaxSize:     viper.GetInt64("max-fetch-size"),
+		Concurrency: viper.GetInt("fetch-concurrency"),
+	}
+	sourcePaths := make([]string, 0, len(updateFiles))
+	for _, arg := range updateFiles {
+		source, _, err := local.ParseFileSpec(arg, separator)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "ParseFileSpec(%s, %s)", arg, separator)
+		}
+		sourcePaths = append(sourcePaths, source)
+	}
+	if err := sources.Prepare(ctx, sourcePaths); err != nil {
+		return nil, nil, err
+	}
+
+	for _, arg := range updateFiles {
+		source, target, err := local.ParseFileSpec(arg, separator)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "ParseFileSpec(%s, %s)", arg, separator)
+		}
+		isFile := source != local.StdinSource && !local.IsURLSource(source)
+		if isFile && isIgnored(source) {
+			result.Warnings.Add("gitignored-explicit", target, "%q is gitignored, but explicitly named: including it", source)
+		}
+		if target, err = normalizeTarget(target); err != nil {
+			return nil, nil, err
+		}
+		updateTargets[target] = true
+		if changedFiles != nil && !changedFiles[target] {
+			log.Infof("%q unchanged since %s: skipping addition", target, viper.GetString("since-commit"))
+			continue
+		}
+
+		content, err := sources.Load(ctx, source)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "Load(%s)", source)
+		}
+		if err := validateAddition(target, content); err != nil {
+			return nil, nil, fmt.Errorf("%q is invalid: refusing addition to %q: %w", arg, target, err)
+		}
+		if scanSecrets && !local.IsBinary(content) {
+			if matches := local.ScanSecrets(content, secretsAllowlist); len(matches) > 0 {
+				if !force {
+					return nil, nil, fmt.Errorf("%q contains a possible secret (%s, line %d): refusing addition to %q", arg, matches[0].Detector, matches[0].Line, target)
+				}
+				for _, match := range matches {
+					result.Warnings.Add("secret-detected", target, "%q contains a possible secret (%s, line %d)", arg, match.Detector, match.Line)
+				}
+			}
+		}
+		var lfs *lfsUpload
+		if lfsMatcher != nil && len(content) > 0 && lfsMatcher.Match(target) {
+			log.Infof("%q is tracked by Git LFS", target)
+			lfs = &lfsUpload{content: content}
+			content, lfs.LFSObject = local.LFSPointer(content)
+		}
+		if viper.GetBool("fail-on-binary") && local.IsBinary(content) {
+			return nil, nil, fmt.Errorf("%q is binary: refusing addition to %q", arg, target)
+		}
+		if len(content) == 0 {
+			switch {
+			case viper.GetBool("fail-empty"):
+				return nil, nil, fmt.Errorf("%q is empty: refusing addition to %q", arg, target)
+			case viper.GetBool("skip-empty"):
+				result.Warnings.Add("empty-skipped", target, "%q is empty: skipping addition to %q", arg, target)
+				continue
+			}
+		}
+		local_hash := local.BlobHash(content, sha256Format)
+		remote_hash := client.GetFileHashV4(owner, repo, string(targetOid), target)
+		log.Infof("local: %s, remote: %s", local_hash, remote_hash)
+		forcePath := slices.ContainsFunc(forcePaths, func(pattern string) bool {
+			matched, _ := path.Match(pattern, target)
+			return matched
+		})
+		if local_hash != remote_hash || force || forcePath {
+			if duplicate := duplicatePath(treePaths, local_hash, target); len(content) > 0 && duplicate != "" {
+				if viper.GetBool("fail-on-duplicate") {
+					return nil, nil, fmt.Errorf("content of %q already exists on target branch at %q: refusing addition to %q", arg, duplicate, target)
+				}
+				result.Warnings.Add("duplicate-content", target, "content of %q already exists on target branch at %q", arg, duplicate)
+			}
+			log.Infof("%q queued for addition", target)
+			additions = append(additions, githubv4.FileAddition{
+				Path:     githubv4.String(target),
+				Contents: githubv4.Base64String(base64.StdEncoding.EncodeToString(content)),
+			})
+			result.Additions = append(result.Additions, fileChange{Path: target, Hash: local_hash, lfs: lfs})
+		} else {
+			log.Infof("%q (%s) on target branch: skipping addition", target, remote_hash)
+		}
+	}
+
+	for i, target := range deleteFiles {
+		if target, err = normalizeTarget(target); err != nil {
+			return nil, nil, err
+		}
+		deleteFiles[i] = target
+		remote_hash := client.GetFileHashV4(owner, repo, string(targetOid), target)
+		if remote_hash != "" || force {
+			log.Infof("%q queued for deletion", target)
+			deletions = append(deletions, githubv4.FileDeletion{
+				Path: githubv4.String(target),
+			})
+			result.Deletions = append(result.Deletions, fileChange{Path: target, Hash: remote_hash})
+		} else {
+			log.Infof("%q absent on target branch: skipping deletion", target)
+		}
+	}
+
+	if manifest := viper.GetString("delete-missing-from"); manifest != "" {
+		manifestFiles, err := local.ReadManifest(manifest)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "ReadManifest(%s)", manifest)
+		}
+		for _, target := range manifestFiles {
+			if target, err = normalizeTarget(target); err != nil {
+				return nil, nil, err
+			}
+			if updateTargets[target] || slices.Contains(deleteFiles, target) {
+				continue
+			}
+			if remote_hash := client.GetFileHashV4(owner, repo, string(targetOid), target); remote_hash != "" {
+				log.Infof("%q missing from update set: queued for deletion", target)
+				deletions = append(deletions, githubv4.FileDeletion{
+					Path: githubv4.String(target),
+				})
+				result.Deletions = append(result.Deletions, fileChange{Path: target, Hash: remote_hash})
+			}
+		}
+	}
+
+	return additions, deletions, nil
+}
+
+// planIdempotencyKey derives a key from the target and the planned changes (or tree),
+// such that identical re-runs carry the same key
+func planIdempotencyKey(treeSHA string, result *contentResult) string {
+	changes := make([]string, 0, len(result.Additions)+len(result.Deletions))
+	for _, addition := range result.Additions {
+		changes = append(changes, fmt.Sprintf("update %s %s", addition.Path, addition.Hash))
+	}
+	for _, deletion := range result.Deletions {
+		changes = append(changes, fmt.Sprintf("delete %s %s", deletion.Path, deletion.Hash))
+	}
+	slices.Sort(changes)
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%s/%s:%s\n", owner, repo, branch)
+	if treeSHA != "" {
+		fmt.Fprintf(h, "tree %s\n", treeSHA)
+	}
+	for _, change := range changes {
+		fmt.Fprintln(h, change)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// topLevelDir returns the first component of target, or "." for root-level files
+func topLevelDir(target string) string {
+	if dir, _, found := strings.Cut(target, "/"); found {
+		return dir
+	}
+	return "."
+}
+
+// splitChangesByDir partitions changes by the top-level directory of each path,
+// returning the directories in sorted order alongside their changes
+func splitChangesByDir(changes githubv4.FileChanges) (dirs []string, groups map[string]githubv4.FileChanges) {
+	groups = map[string]githubv4.FileChanges{}
+	group := func(target githubv4.String) githubv4.FileChanges {
+		dir := topLevelDir(string(target))
+		g, ok := groups[dir]
+		if !ok {
+			g = githubv4.FileChanges{
+				Additions: &[]githubv4.FileAddition{},
+				Deletions: &[]githubv4.FileDeletion{},
+			}
+			groups[dir] = g
+			dirs = append(dirs, dir)
+		}
+		return g
+	}
+	for _, addition := range *changes.Additions {
+		g := group(addition.Path)
+		*g.Additions = append(*g.Additions, addition)
+	}
+	for _, deletion := range *changes.Deletions {
+		g := group(deletion.Path)
+		*g.Deletions = append(*g.Deletions, deletion)
+	}
+	slices.Sort(dirs)
+	return
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+
+	"github.com/nexthink-oss/ghup/internal/remote"
+)
+
+const redacted = "[REDACTED]"
+
+type contentInputs struct {
+	Owner     string                 `json:"owner"`
+	Repo      string                 `json:"repo"`
+	Branch    string                 `json:"branch"`
+	Updates   []string               `json:"updates"`
+	Deletions []string               `json:"deletions"`
+	Settings  map[string]interface{} `json:"settings"`
+}
+
+// contentReport is the audit record written by --report-file
+type contentReport struct {
+	Inputs     contentInputs  `json:"inputs"`
+	Result     *contentResult `json:"result"`
+	Error      string         `json:"error,omitempty"`
+	Retries    int            `json:"retries"`
+	StartedAt  time.Time      `json:"started_at"`
+	FinishedAt time.Time      `json:"finished_at"`
+	Duration   string         `json:"duration"`
+
+	// client, once created, counts the retries made
+	client *remote.TokenClient
+}
+
+func newContentReport(args []string, result *contentResult) *contentReport {
+	settings := viper.AllSettings()
+	if _, ok := settings["token"]; ok {
+		settings["token"] = redacted
+	}
+
+	return &contentReport{
+		Inputs: contentInputs{
+			Updates:   append(append([]string{}, args...), viper.GetStringSlice("update")...),
+			Deletions: viper.GetStringSlice("delete"),
+			Settings:  settings,
+		},
+		Result:    result,
+		StartedAt: time.Now(),
+	}
+}
+
+// Write completes the report with the final target and outcome, and writes it to file
+func (r *contentReport) Write(file string, err error) error {
+	r.Inputs.Owner, r.Inputs.Repo, r.Inputs.Branch = owner, repo, branch
+	// a requested exit code is not a failure
+	var exitErr *exitCodeError
+	if err != nil && !errors.As(err, &exitErr) {
+		r.Error = err.Error()
+	}
+	if r.client != nil {
+		r.Retries = r.client.Retries()
+	}
+	r.FinishedAt = time.Now()
+	r.Duration = r.FinishedAt.Sub(r.StartedAt).String()
+
+	m, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, append(m, '\n'), 0o644)
+}
@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+
+	"github.com/apex/log"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/nexthink-oss/ghup/internal/local"
+	"github.com/nexthink-oss/ghup/internal/remote"
+)
+
+var diffLocalCmd = &cobra.Command{
+	Use:     "diff-local [flags] <local-dir>[:<remote-prefix>]",
+	Short:   "Compare a local directory against the target branch",
+	Args:    cobra.ExactArgs(1),
+	PreRunE: validateFlags,
+	RunE:    runDiffLocalCmd,
+}
+
+func init() {
+	diffLocalCmd.Flags().Bool("name-only", false, "only list the paths of differing files")
+	viper.BindPFlag("name-only", diffLocalCmd.Flags().Lookup("name-only"))
+
+	diffLocalCmd.Flags().StringP("output-file", "O", "", "write to `path` instead of stdout")
+	viper.BindPFlag("output-file", diffLocalCmd.Flags().Lookup("output-file"))
+
+	diffLocalCmd.Flags().SortFlags = false
+
+	contentCmd.AddCommand(diffLocalCmd)
+}
+
+func runDiffLocalCmd(cmd *cobra.Command, args []string) (err error) {
+	ctx := context.Background()
+
+	client, err := remote.NewTokenClient(ctx, viper.GetString("token"), clientOptions()...)
+	if err != nil {
+		return errors.Wrap(err, "NewTokenClient")
+	}
+
+	separator := viper.GetString("separator")
+	if len(separator) < 1 {
+		return fmt.Errorf("invalid separator")
+	}
+
+	localDir, prefix, err := local.ParseFileSpec(args[0], separator)
+	if err != nil {
+		return errors.Wrapf(err, "ParseFileSpec(%s)", args[0])
+	}
+	// as for file-specs, the remote prefix defaults to the local directory path, with "." the root
+	if path.Clean(filepath.ToSlash(prefix)) == "." {
+		prefix = ""
+	} else if prefix, err = normalizeTarget(prefix); err != nil {
+		return err
+	}
+
+	localFiles, err := listLocalFiles(localDir, prefix)
+	if err != nil {
+		return errors.Wrapf(err, "listLocalFiles(%s)", localDir)
+	}
+
+	// pin the branch tip, so that the listing and contents compared are from the same commit
+	commit, err := client.GetRefOidV4(owner, repo, branch)
+	if err != nil {
+		return errors.Wrapf(err, "GetRefOidV4(%s, %s, %s)", owner, repo, branch)
+	}
+
+	remoteEntries, err := client.ListTreeV4(owner, repo, string(commit), prefix)
+	if err != nil {
+		return errors.Wrapf(err, "ListTreeV4(%s, %s, %s, %s)", owner, repo, commit, prefix)
+	}
+
+	remoteFiles := make(map[string]remote.TreeEntry, len(remoteEntries))
+	for _, entry := range remoteEntries {
+		remoteFiles[entry.Path] = entry
+	}
+
+	paths := make([]string, 0, len(localFiles)+len(remoteFiles))
+	for target := range localFiles {
+		paths = append(paths, target)
+	}
+	for target := range remoteFiles {
+		if _, ok := localFiles[target]; !ok {
+			paths = append(paths, target)
+		}
+	}
+	slices.Sort(paths)
+
+	diffs := []local.FileDiff{}
+	for _, target := range paths {
+		fileDiff := local.FileDiff{Path: target}
+
+		if source, ok := localFiles[target]; ok {
+			to, err := readLocalVersion(source)
+			if err != nil {
+				return errors.Wrapf(err, "readLocalVersion(%s)", source)
+			}
+			if entry, ok := remoteFiles[target]; ok &&
+				entry.Oid == local.BlobHash(to.Content, local.IsSHA256(entry.Oid)) &&
+				filemode.FileMode(entry.Mode) == to.Mode {
+				log.Infof("%q unchanged", target)
+				continue
+			}
+			fileDiff.To = to
+		}
+
+		if entry, ok := remoteFiles[target]; ok {
+			content, err := getEntryContent(client, string(commit), entry)
+			if err != nil {
+				return err
+			}
+			fileDiff.From = &local.FileVersion{
+				Mode:    filemode.FileMode(entry.Mode),
+				Content: content,
+			}
+		}
+
+		diffs = append(diffs, fileDiff)
+	}
+
+	var w io.Writer = os.Stdout
+	if outputFile := viper.GetString("output-file"); outputFile != "" {
+		f, err := os.Create(outputFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if viper.GetBool("name-only") {
+		for _, d := range diffs {
+			fmt.Fprintln(w, d.Path)
+		}
+		return nil
+	}
+
+	return local.WritePatch(w, diffs)
+}
+
+// listLocalFiles maps remote target paths under prefix to regular files and symbolic links found beneath localDir
+func listLocalFiles(localDir string, prefix string) (files map[string]string, err error) {
+	files = make(map[string]string)
+	err = filepath.WalkDir(localDir, func(source string, d fs.DirEntry, err error) error {
+		switch {
+		case err != nil:
+			return err
+		case d.IsDir() && d.Name() == ".git":
+			return filepath.SkipDir
+		case !d.Type().IsRegular() && d.Type()&fs.ModeSymlink == 0:
+			return nil
+		}
+		rel, err := filepath.Rel(localDir, source)
+		if err != nil {
+			return err
+		}
+		files[path.Join(prefix, filepath.ToSlash(rel))] = source
+		return nil
+	})
+	return
+}
+
+// readLocalVersion reads the mode and content of source, that of a symbolic link being its target, as in git
+func readLocalVersion(source string) (*local.FileVersion, error) {
+	info, err := os.Lstat(source)
+	if err != nil {
+		return nil, err
+	}
+	if info.Mode()&fs.ModeSymlink != 0 {
+		target, err := os.Readlink(source)
+		if err != nil {
+			return nil, err
+		}
+		return &local.FileVersion{Mode: filemode.Symlink, Content: []byte(filepath.ToSlash(target))}, nil
+	}
+	mode, err := filemode.NewFromOSFileMode(info.Mode())
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(source)
+	if err != nil {
+		return nil, err
+	}
+	return &local.FileVersion{Mode: mode, Content: content}, nil
+}
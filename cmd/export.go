@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/apex/log"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/nexthink-oss/ghup/internal/local"
+	"github.com/nexthink-oss/ghup/internal/remote"
+)
+
+var exportCmd = &cobra.Command{
+	Use:     "export [flags] <local-dir>",
+	Short:   "Export the target branch tree to a local directory",
+	Args:    cobra.ExactArgs(1),
+	PreRunE: validateFlags,
+	RunE:    runExportCmd,
+}
+
+func init() {
+	exportCmd.Flags().String("prefix", "", "only export the subtree at remote `path`")
+	viper.BindPFlag("prefix", exportCmd.Flags().Lookup("prefix"))
+
+	exportCmd.Flags().Int("concurrency", 4, "maximum concurrent file downloads")
+	viper.BindPFlag("concurrency", exportCmd.Flags().Lookup("concurrency"))
+	viper.BindEnv("concurrency", "GHUP_CONCURRENCY")
+
+	exportCmd.Flags().SortFlags = false
+
+	contentCmd.AddCommand(exportCmd)
+}
+
+func runExportCmd(cmd *cobra.Command, args []string) (err error) {
+	ctx := context.Background()
+
+	client, err := remote.NewTokenClient(ctx, viper.GetString("token"), clientOptions()...)
+	if err != nil {
+		return errors.Wrap(err, "NewTokenClient")
+	}
+
+	concurrency := viper.GetInt("concurrency")
+	if concurrency < 1 {
+		return fmt.Errorf("invalid concurrency %d", concurrency)
+	}
+
+	localDir := args[0]
+	prefix := strings.Trim(viper.GetString("prefix"), "/")
+
+	// pin the branch tip, so that all files are exported from the same commit
+	commit, err := client.GetRefOidV4(owner, repo, branch)
+	if err != nil {
+		return errors.Wrapf(err, "GetRefOidV4(%s, %s, %s)", owner, repo, branch)
+	}
+
+	entries, err := client.ListTreeV4(owner, repo, string(commit), prefix)
+	if err != nil {
+		return errors.Wrapf(err, "ListTreeV4(%s, %s, %s, %s)", owner, repo, commit, prefix)
+	}
+	log.Infof("exporting %d files from %s", len(entries), commit)
+
+	var wg sync.WaitGroup
+	var errOnce sync.Once
+	var firstErr error
+	slots := make(chan struct{}, concurrency)
+	for _, entry := range entries {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			if err := exportEntry(client, string(commit), entry, localDir, prefix); err != nil {
+				errOnce.Do(func() { firstErr = err })
+			}
+		}()
+	}
+	wg.Wait()
+
+	return firstErr
+}
+
+// exportEntry writes the blob of entry beneath localDir, relative to prefix, applying its mode where possible
+func exportEntry(client *remote.TokenClient, ref string, entry remote.TreeEntry, localDir string, prefix string) error {
+	rel := entry.Path
+	if prefix != "" {
+		rel = strings.TrimPrefix(entry.Path, prefix+"/")
+	}
+	if !filepath.IsLocal(filepath.FromSlash(rel)) {
+		return fmt.Errorf("refusing to export %q outside %q", entry.Path, localDir)
+	}
+	target := filepath.Join(localDir, filepath.FromSlash(rel))
+
+	content, err := getEntryContent(client, ref, entry)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+
+	mode := filemode.FileMode(entry.Mode)
+	if mode == filemode.Symlink {
+		log.Infof("%q -> %s", entry.Path, content)
+		os.Remove(target)
+		return os.Symlink(filepath.FromSlash(string(content)), target)
+	}
+
+	perm, err := mode.ToOSFileMode()
+	if err != nil || !perm.IsRegular() {
+		perm = 0o644
+	}
+	log.Infof("%q (%s)", entry.Path, mode)
+	if err := os.WriteFile(target, content, perm.Perm()); err != nil {
+		return err
+	}
+	// WriteFile does not change the mode of an existing file
+	return os.Chmod(target, perm.Perm())
+}
+
+// getEntryContent returns the exact content of the blob of entry on ref, fetching it by object ID
+// if the content returned by GraphQL (decoded text) does not hash to it
+func getEntryContent(client *remote.TokenClient, ref string, entry remote.TreeEntry) ([]byte, error) {
+	content, err := client.GetFileContentV4(owner, repo, ref, entry.Path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "GetFileContentV4(%s, %s, %s, %s)", owner, repo, ref, entry.Path)
+	}
+	sha256Format := local.IsSHA256(entry.Oid)
+	if local.BlobHash(content, sha256Format) == entry.Oid {
+		return content, nil
+	}
+
+	log.Infof("%q text does not match blob %s: fetching raw content", entry.Path, entry.Oid)
+	content, err = client.GetBlobV3(client.Context, owner, repo, entry.Oid)
+	if err != nil {
+		return nil, errors.Wrapf(err, "GetBlobV3(%s, %s, %s)", owner, repo, entry.Oid)
+	}
+	if hash := local.BlobHash(content, sha256Format); hash != entry.Oid {
+		return nil, fmt.Errorf("content of %q hashes to %s, not blob %s", entry.Path, hash, entry.Oid)
+	}
+	return content, nil
+}
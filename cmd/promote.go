@@ -0,0 +1,270 @@
+package cmd
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/pkg/errors"
+	"github.com/shurcooL/githubv4"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/nexthink-oss/ghup/internal/remote"
+	"github.com/nexthink-oss/ghup/internal/util"
+)
+
+var promoteCmd = &cobra.Command{
+	Use:     "promote [flags]",
+	Short:   "Promote subtrees from one branch to another via a pull request",
+	Args:    cobra.NoArgs,
+	PreRunE: validateFlags,
+	RunE:    runPromoteCmd,
+}
+
+func init() {
+	promoteCmd.Flags().String("from", "", "source branch `name`")
+	viper.BindPFlag("from", promoteCmd.Flags().Lookup("from"))
+	viper.BindEnv("from", "GHUP_FROM")
+
+	promoteCmd.Flags().String("to", "", `destination branch `+"`name`"+` (default: "[remote-default-branch]")`)
+	viper.BindPFlag("to", promoteCmd.Flags().Lookup("to"))
+	viper.BindEnv("to", "GHUP_TO")
+
+	promoteCmd.Flags().StringSlice("path", []string{}, "subtree `path` to promote (repeatable)")
+	viper.BindPFlag("path", promoteCmd.Flags().Lookup("path"))
+
+	promoteCmd.Flags().String("promote-branch", "", `pull request head branch `+"`name`"+` (default: "promote/[from]-to-[to]")`)
+	viper.BindPFlag("promote-branch", promoteCmd.Flags().Lookup("promote-branch"))
+	viper.BindEnv("promote-branch", "GHUP_PROMOTE_BRANCH")
+
+	promoteCmd.Flags().String("promote-title", "", `pull request `+"`title`"+` (default: "Promote [paths] from [from] to [to]")`)
+	viper.BindPFlag("promote-title", promoteCmd.Flags().Lookup("promote-title"))
+
+	promoteCmd.Flags().Bool("dry-run", false, "list the changes to promote, without creating a branch, commit or pull request")
+	viper.BindPFlag("dry-run", promoteCmd.Flags().Lookup("dry-run"))
+	viper.BindEnv("dry-run", "GHUP_DRY_RUN")
+
+	promoteCmd.MarkFlagRequired("from")
+	promoteCmd.MarkFlagRequired("path")
+
+	promoteCmd.Flags().SortFlags = false
+
+	contentCmd.AddCommand(promoteCmd)
+}
+
+func runPromoteCmd(cmd *cobra.Command, args []string) (err error) {
+	ctx := context.Background()
+
+	client, err := remote.NewTokenClient(ctx, viper.GetString("token"), clientOptions()...)
+	if err != nil {
+		return errors.Wrap(err, "NewTokenClient")
+	}
+
+	from := viper.GetString("from")
+	paths := []string{}
+	for _, p := range viper.GetStringSlice("path") {
+		if p = strings.Trim(p, "/"); p != "" {
+			paths = append(paths, p)
+		}
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no path specified")
+	}
+
+	repoInfo, err := client.GetRepositoryInfo(owner, repo, from)
+	switch {
+	case errors.Is(err, remote.ErrRepositoryArchived):
+		if !viper.GetBool("dry-run") {
+			return err
+		}
+	case err != nil:
+		return errors.Wrapf(err, "GetRepositoryInfo(%s, %s, %s)", owner, repo, from)
+	}
+	to := viper.GetString("to")
+	if to == "" {
+		to = repoInfo.DefaultBranch.Name
+	}
+	if to == from {
+		return fmt.Errorf("cannot promote %q to itself", from)
+	}
+
+	// pin both tips, so that the promotion is computed between consistent commits
+	fromOid, err := client.GetRefOidV4(owner, repo, from)
+	if err != nil {
+		return errors.Wrapf(err, "GetRefOidV4(%s, %s, %s)", owner, repo, from)
+	}
+	toOid, err := client.GetRefOidV4(owner, repo, to)
+	if err != nil {
+		return errors.Wrapf(err, "GetRefOidV4(%s, %s, %s)", owner, repo, to)
+	}
+	log.Infof("promoting %s from %q (%s) to %q (%s)", strings.Join(paths, ", "), from, fromOid, to, toOid)
+
+	// the shared branch variable designates the branch committed to
+	branch = viper.GetString("promote-branch")
+	if branch == "" {
+		branch = fmt.Sprintf("promote/%s-to-%s", from, to)
+	}
+
+	// an existing promotion branch (e.g. from an earlier run) is rebuilt from the --to tip,
+	// keeping any open pull request from it
+	branchOid, err := client.GetRefOidV4(owner, repo, branch)
+	branchExists := err == nil
+	if !branchExists && !errors.Is(err, remote.ErrRefNotFound) {
+		return errors.Wrapf(err, "GetRefOidV4(%s, %s, %s)", owner, repo, branch)
+	}
+
+	result := contentResult{
+		Additions: []fileChange{},
+		Deletions: []fileChange{},
+		Warnings:  contentWarnings{},
+	}
+	additions := []githubv4.FileAddition{}
+	deletions := []githubv4.FileDeletion{}
+	modes := map[string]string{}
+	for _, prefix := range paths {
+		fromEntries, err := client.ListTreeV4(owner, repo, string(fromOid), prefix)
+		if err != nil {
+			return errors.Wrapf(err, "ListTreeV4(%s, %s, %s, %s)", owner, repo, fromOid, prefix)
+		}
+		if len(fromEntries) == 0 {
+			return fmt.Errorf("no files under %q on %q", prefix, from)
+		}
+		toEntries, err := client.ListTreeV4(owner, repo, string(toOid), prefix)
+		if err != nil {
+			return errors.Wrapf(err, "ListTreeV4(%s, %s, %s, %s)", owner, repo, toOid, prefix)
+		}
+
+		toFiles := make(map[string]remote.TreeEntry, len(toEntries))
+		for _, entry := range toEntries {
+			toFiles[entry.Path] = entry
+		}
+
+		fromPaths := make(map[string]bool, len(fromEntries))
+		for _, entry := range fromEntries {
+			fromPaths[entry.Path] = true
+			if dest, ok := toFiles[entry.Path]; ok && dest.Oid == entry.Oid && dest.Mode == entry.Mode {
+				log.Debugf("%q unchanged", entry.Path)
+				continue
+			}
+			content, err := getEntryContent(client, string(fromOid), entry)
+			if err != nil {
+				return err
+			}
+			additions = append(additions, githubv4.FileAddition{
+				Path:     githubv4.String(entry.Path),
+				Contents: githubv4.Base64String(base64.StdEncoding.EncodeToString(content)),
+			})
+			// let other additions keep their existing mode, or default to a regular file
+			if dest, ok := toFiles[entry.Path]; entry.Mode != int(filemode.Regular) || (ok && dest.Mode != entry.Mode) {
+				modes[entry.Path] = fmt.Sprintf("%06o", entry.Mode)
+			}
+			result.Additions = append(result.Additions, fileChange{Path: entry.Path, Hash: entry.Oid})
+		}
+
+		// mirror the subtree, so that files removed from the source are removed from the destination
+		for _, entry := range toEntries {
+			if !fromPaths[entry.Path] {
+				deletions = append(deletions, githubv4.FileDeletion{Path: githubv4.String(entry.Path)})
+				result.Deletions = append(result.Deletions, fileChange{Path: entry.Path, Hash: entry.Oid})
+			}
+		}
+	}
+	slices.SortFunc(result.Additions, func(a, b fileChange) int { return strings.Compare(a.Path, b.Path) })
+	slices.SortFunc(result.Deletions, func(a, b fileChange) int { return strings.Compare(a.Path, b.Path) })
+
+	if viper.GetBool("dry-run") {
+		for _, addition := range result.Additions {
+			fmt.Printf("update\t%s\n", addition.Path)
+		}
+		for _, deletion := range result.Deletions {
+			fmt.Printf("delete\t%s\n", deletion.Path)
+		}
+		return nil
+	}
+
+	if len(additions) == 0 && len(deletions) == 0 {
+		result.Warnings.Add("nothing-to-do", "", "nothing to do")
+		printContentResult(result)
+		return nil
+	}
+
+	if branchExists {
+		result.PullRequest, err = client.GetOpenPullRequestV4(owner, repo, branch, to)
+		if err != nil {
+			return errors.Wrapf(err, "GetOpenPullRequestV4(%s, %s, %s, %s)", owner, repo, branch, to)
+		}
+	}
+
+	title := viper.GetString("promote-title")
+	if title == "" {
+		title = fmt.Sprintf("Promote %s from %s to %s", strings.Join(paths, ", "), from, to)
+	}
+	message := title
+	if viper.IsSet("message") {
+		message = viper.GetString("message")
+	}
+
+	if result.PullRequest == "" {
+		if err := checkOpenPullRequests(client); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case branchExists && branchOid != toOid:
+		log.Infof("resetting existing branch %q (%s) to %q (%s)", branch, branchOid, to, toOid)
+		if err := client.ResetBranchV3(ctx, owner, repo, branch, string(toOid)); err != nil {
+			return errors.Wrapf(err, "ResetBranchV3(%s, %s, %s, %s)", owner, repo, branch, toOid)
+		}
+	case !branchExists:
+		log.Infof("creating branch %q", branch)
+		createRefInput := githubv4.CreateRefInput{
+			RepositoryID: repoInfo.NodeID,
+			Name:         githubv4.String(fmt.Sprintf("refs/heads/%s", branch)),
+			Oid:          toOid,
+		}
+		log.Debugf("CreateRefInput: %+v", createRefInput)
+		if err := client.CreateRefV4(createRefInput); err != nil {
+			return errors.Wrap(err, "CreateRefV4")
+		}
+	}
+
+	changes := githubv4.FileChanges{
+		Additions: &additions,
+		Deletions: &deletions,
+	}
+	result.Commit, result.Url, err = commitContent(ctx, client, toOid, changes, modes, util.ComposeCommitMessage(message))
+	if err != nil {
+		return err
+	}
+	log.Infof("committed %s", result.Url)
+
+	if result.PullRequest != "" {
+		log.Infof("updated pull request %s", result.PullRequest)
+		printContentResult(result)
+		return nil
+	}
+
+	log.Infof("opening pull request from %q to %q", branch, to)
+	body := githubv4.String(fmt.Sprintf("Promotes %s from `%s` (%s) to `%s`.", strings.Join(paths, ", "), from, fromOid, to))
+	input := githubv4.CreatePullRequestInput{
+		RepositoryID: repoInfo.NodeID,
+		BaseRefName:  githubv4.String(to),
+		HeadRefName:  githubv4.String(branch),
+		Title:        githubv4.String(title),
+		Body:         &body,
+	}
+	log.Debugf("CreatePullRequestInput: %+v", input)
+	result.PullRequest, err = client.CreatePullRequestV4(input)
+	if err != nil {
+		return errors.Wrap(err, "CreatePullRequestV4")
+	}
+
+	printContentResult(result)
+	return nil
+}
@@ -2,11 +2,13 @@ package cmd
 
 import (
 	"cmp"
+	"errors"
 	"fmt"
 	"os"
 	"strings"
 
 	"github.com/nexthink-oss/ghup/internal/local"
+	"github.com/nexthink-oss/ghup/internal/remote"
 	"github.com/nexthink-oss/ghup/internal/util"
 
 	"github.com/apex/log"
@@ -45,10 +47,23 @@ var rootCmd = &cobra.Command{
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute() {
 	if err := rootCmd.Execute(); err != nil {
+		var exitErr *exitCodeError
+		if errors.As(err, &exitErr) {
+			os.Exit(exitErr.code)
+		}
 		os.Exit(1)
 	}
 }
 
+// exitCodeError requests a specific exit code, rather than signalling failure
+type exitCodeError struct {
+	code int
+}
+
+func (e *exitCodeError) Error() string {
+	return fmt.Sprintf("exit code %d", e.code)
+}
+
 func init() {
 	cobra.OnInitialize(initViper, initLogger)
 
@@ -77,6 +92,10 @@ func init() {
 		defaultBranch = localRepo.Branch
 	}
 
+	rootCmd.PersistentFlags().String("config", "", "read configuration from `file` (JSON, YAML or TOML)")
+	viper.BindPFlag("config", rootCmd.PersistentFlags().Lookup("config"))
+	viper.BindEnv("config", "GHUP_CONFIG")
+
 	rootCmd.PersistentFlags().CountP("verbosity", "v", "verbosity")
 	viper.BindPFlag("verbosity", rootCmd.PersistentFlags().Lookup("verbosity"))
 
@@ -98,6 +117,14 @@ func init() {
 	rootCmd.PersistentFlags().StringP("message", "m", "Commit via API", "message")
 	viper.BindPFlag("message", rootCmd.PersistentFlags().Lookup("message"))
 
+	rootCmd.PersistentFlags().String("subject-prefix", "", "`prefix` for the message subject line, unless already present")
+	viper.BindPFlag("subject-prefix", rootCmd.PersistentFlags().Lookup("subject-prefix"))
+	viper.BindEnv("subject-prefix", "GHUP_SUBJECT_PREFIX")
+
+	rootCmd.PersistentFlags().String("message-trailer-separator", "", "`line` to separate message body from trailers, as its own paragraph")
+	viper.BindPFlag("message-trailer-separator", rootCmd.PersistentFlags().Lookup("message-trailer-separator"))
+	viper.BindEnv("message-trailer-separator", "GHUP_MESSAGE_TRAILER_SEPARATOR")
+
 	rootCmd.PersistentFlags().String("author.trailer", "Co-Authored-By", "`key` for commit author trailer (blank to disable)")
 	viper.BindPFlag("author.trailer", rootCmd.PersistentFlags().Lookup("author.trailer"))
 	viper.BindEnv("author.trailer", "GHUP_TRAILER_KEY")
@@ -113,19 +140,46 @@ func init() {
 	rootCmd.PersistentFlags().StringToString("trailer", nil, "extra `key=value` commit trailers")
 	viper.BindPFlag("trailer", rootCmd.PersistentFlags().Lookup("trailer"))
 
+	rootCmd.PersistentFlags().StringToString("trailer-from-env", nil, "extra `key=VARIABLE` commit trailers, valued from the environment if set")
+	viper.BindPFlag("trailer-from-env", rootCmd.PersistentFlags().Lookup("trailer-from-env"))
+
 	rootCmd.PersistentFlags().BoolVarP(&force, "force", "f", false, "force action")
 	viper.BindPFlag("force", rootCmd.PersistentFlags().Lookup("force"))
 
+	rootCmd.PersistentFlags().Int("max-concurrency-per-host", 0, "maximum concurrent API requests per host (0 for unlimited)")
+	viper.BindPFlag("max-concurrency-per-host", rootCmd.PersistentFlags().Lookup("max-concurrency-per-host"))
+	viper.BindEnv("max-concurrency-per-host", "GHUP_MAX_CONCURRENCY_PER_HOST")
+
+	rootCmd.PersistentFlags().Int("max-retries", 0, "maximum retries of each transiently failing API request")
+	viper.BindPFlag("max-retries", rootCmd.PersistentFlags().Lookup("max-retries"))
+	viper.BindEnv("max-retries", "GHUP_MAX_RETRIES")
+
+	rootCmd.PersistentFlags().Int("retry-budget", -1, "maximum retries across all API requests of the run (-1 for unlimited)")
+	viper.BindPFlag("retry-budget", rootCmd.PersistentFlags().Lookup("retry-budget"))
+	viper.BindEnv("retry-budget", "GHUP_RETRY_BUDGET")
+
+	rootCmd.PersistentFlags().String("api-version", "2022-11-28", "REST API `version` requested via the X-GitHub-Api-Version header")
+	viper.BindPFlag("api-version", rootCmd.PersistentFlags().Lookup("api-version"))
+	viper.BindEnv("api-version", "GHUP_API_VERSION")
+
 	rootCmd.Flags().SortFlags = false
 	rootCmd.PersistentFlags().SortFlags = false
 }
 
-// initViper initializes Viper to load config from the environment
+// initViper initializes Viper to load config from the environment and any config file
 func initViper() {
 	viper.SetEnvPrefix("GHUP")
 	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_", "-", "_"))
 	viper.AutomaticEnv()      // read in environment variables that match bound variables
 	viper.AllowEmptyEnv(true) // respect empty environment variables
+
+	if config := viper.GetString("config"); config != "" {
+		viper.SetConfigFile(config)
+		if err := viper.ReadInConfig(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: reading config %q: %s\n", config, err)
+			os.Exit(1)
+		}
+	}
 }
 
 // initLogger initializes the logger subsystem
@@ -136,6 +190,15 @@ func initLogger() {
 	log.SetLevel(log.Level(int(log.WarnLevel) - verbosity))
 }
 
+// clientOptions returns the remote client options derived from configuration
+func clientOptions() []remote.ClientOption {
+	return []remote.ClientOption{
+		remote.WithMaxConcurrencyPerHost(viper.GetInt("max-concurrency-per-host")),
+		remote.WithRetries(viper.GetInt("max-retries"), viper.GetInt("retry-budget")),
+		remote.WithAPIVersion(viper.GetString("api-version")),
+	}
+}
+
 // validateFlags checks mandatory flags are valid and stores results in shared variables
 func validateFlags(cmd *cobra.Command, args []string) error {
 	owner = cmp.Or[string](viper.GetString("owner"), defaultOwner)
@@ -153,5 +216,11 @@ func validateFlags(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("no branch specified")
 	}
 
+	if separator := viper.GetString("message-trailer-separator"); separator != "" {
+		if err := util.ValidateTrailerSeparator(separator); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
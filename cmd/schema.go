@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/nexthink-oss/ghup/internal/util"
+)
+
+// schemaTypes maps the structured outputs of commands to the types they encode
+var schemaTypes = map[string]interface{}{
+	"plan":   contentPlan{},
+	"result": contentResult{},
+}
+
+var schemaCmd = &cobra.Command{
+	Use:       "schema <plan|result>",
+	Short:     "Print the JSON Schema of structured content output",
+	Hidden:    true,
+	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	ValidArgs: []string{"plan", "result"},
+	RunE:      runSchemaCmd,
+}
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+}
+
+func runSchemaCmd(cmd *cobra.Command, args []string) (err error) {
+	schema := util.JSONSchema(schemaTypes[args[0]], fmt.Sprintf("ghup content %s", args[0]))
+
+	m, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(m))
+	return nil
+}
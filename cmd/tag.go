@@ -38,7 +38,7 @@ func init() {
 func runTagCmd(cmd *cobra.Command, args []string) (err error) {
 	ctx := context.Background()
 
-	client, err := remote.NewTokenClient(ctx, viper.GetString("token"))
+	client, err := remote.NewTokenClient(ctx, viper.GetString("token"), clientOptions()...)
 	if err != nil {
 		return errors.Wrap(err, "NewTokenClient")
 	}
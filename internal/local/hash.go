@@ -0,0 +1,27 @@
+package local
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+)
+
+// IsSHA256 reports whether oid is a SHA-256 object id, as used by SHA-256 object-format repositories
+func IsSHA256(oid string) bool {
+	return len(oid) == 2*sha256.Size
+}
+
+// BlobHash computes the git blob object id of content, with SHA-256 if sha256Format is set, else SHA-1
+func BlobHash(content []byte, sha256Format bool) string {
+	var h hash.Hash
+	if sha256Format {
+		h = sha256.New()
+	} else {
+		h = sha1.New()
+	}
+	fmt.Fprintf(h, "blob %d\x00", len(content))
+	h.Write(content)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,45 @@
+package local
+
+import "testing"
+
+func TestBlobHash(t *testing.T) {
+	tests := []struct {
+		name         string
+		content      string
+		sha256Format bool
+		want         string
+	}{
+		{
+			name: "Empty SHA-1",
+			want: "e69de29bb2d1d6434b8b29ae775ad8c2e48c5391",
+		},
+		{
+			name:         "Empty SHA-256",
+			sha256Format: true,
+			want:         "473a0f4c3be8a93681a267e3b1e9a7dcda1185436fe141f7749120a303721813",
+		},
+		{
+			name:    "Content SHA-1",
+			content: "hello\n",
+			want:    "ce013625030ba8dba906f756967f9e9ca394464a",
+		},
+		{
+			name:         "Content SHA-256",
+			content:      "hello\n",
+			sha256Format: true,
+			want:         "2cf8d83d9ee29543b34a87727421fdecb7e3f3a183d337639025de576db9ebb4",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BlobHash([]byte(tt.content), tt.sha256Format)
+			if got != tt.want {
+				t.Errorf("BlobHash() = %v, want %v", got, tt.want)
+			}
+			if IsSHA256(got) != tt.sha256Format {
+				t.Errorf("IsSHA256(%v) = %v, want %v", got, !tt.sha256Format, tt.sha256Format)
+			}
+		})
+	}
+}
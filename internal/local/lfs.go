@@ -0,0 +1,62 @@
+package local
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing/format/gitattributes"
+)
+
+// LFSObject describes the content referenced by a Git LFS pointer
+type LFSObject struct {
+	Oid  string
+	Size int64
+}
+
+// LFSPointer returns the Git LFS pointer file for content, along with the object it references
+func LFSPointer(content []byte) (pointer []byte, object LFSObject) {
+	sum := sha256.Sum256(content)
+	object = LFSObject{
+		Oid:  hex.EncodeToString(sum[:]),
+		Size: int64(len(content)),
+	}
+	pointer = []byte(fmt.Sprintf("version https://git-lfs.github.com/spec/v1\noid sha256:%s\nsize %d\n", object.Oid, object.Size))
+	return
+}
+
+// LFSMatcher matches paths tracked by Git LFS
+type LFSMatcher struct {
+	matcher gitattributes.Matcher
+}
+
+// NewLFSMatcher creates an LFSMatcher from the content of a .gitattributes file
+// and any additional patterns to be treated as "<pattern> filter=lfs"
+func NewLFSMatcher(attributes []byte, patterns []string) (*LFSMatcher, error) {
+	stack, err := gitattributes.ReadAttributes(bytes.NewReader(attributes), nil, true)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pattern := range patterns {
+		attribute, err := gitattributes.ParseAttributesLine(fmt.Sprintf("%s filter=lfs", pattern), nil, false)
+		if err != nil {
+			return nil, err
+		}
+		stack = append(stack, attribute)
+	}
+
+	return &LFSMatcher{matcher: gitattributes.NewMatcher(stack)}, nil
+}
+
+// Match reports whether the target path is tracked by Git LFS
+func (m *LFSMatcher) Match(target string) bool {
+	results, matched := m.matcher.Match(strings.Split(target, "/"), []string{"filter"})
+	if !matched {
+		return false
+	}
+	filter, ok := results["filter"]
+	return ok && filter.IsValueSet() && filter.Value() == "lfs"
+}
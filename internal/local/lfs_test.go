@@ -0,0 +1,72 @@
+package local
+
+import (
+	"testing"
+)
+
+func TestLFSPointer(t *testing.T) {
+	pointer, object := LFSPointer([]byte("test content\n"))
+
+	wantOid := "a1fff0ffefb9eace7230c24e50731f0a91c62f9cefdfe77121c2f607125dffae"
+	if object.Oid != wantOid {
+		t.Errorf("LFSPointer() oid = %v, want %v", object.Oid, wantOid)
+	}
+	if object.Size != 13 {
+		t.Errorf("LFSPointer() size = %v, want %v", object.Size, 13)
+	}
+
+	wantPointer := "version https://git-lfs.github.com/spec/v1\noid sha256:" + wantOid + "\nsize 13\n"
+	if string(pointer) != wantPointer {
+		t.Errorf("LFSPointer() pointer = %q, want %q", pointer, wantPointer)
+	}
+}
+
+func TestLFSMatcher(t *testing.T) {
+	attributes := []byte("*.psd filter=lfs diff=lfs merge=lfs -text\nassets/** filter=lfs\n*.txt text\n")
+
+	tests := []struct {
+		name     string
+		patterns []string
+		target   string
+		want     bool
+	}{
+		{
+			name:   "Extension attribute",
+			target: "design/logo.psd",
+			want:   true,
+		},
+		{
+			name:   "Directory attribute",
+			target: "assets/video.mp4",
+			want:   true,
+		},
+		{
+			name:   "Non-LFS attribute",
+			target: "README.txt",
+			want:   false,
+		},
+		{
+			name:   "No attribute",
+			target: "main.go",
+			want:   false,
+		},
+		{
+			name:     "Additional pattern",
+			patterns: []string{"*.bin"},
+			target:   "firmware/image.bin",
+			want:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matcher, err := NewLFSMatcher(attributes, tt.patterns)
+			if err != nil {
+				t.Fatalf("NewLFSMatcher() error = %v", err)
+			}
+			if got := matcher.Match(tt.target); got != tt.want {
+				t.Errorf("LFSMatcher.Match(%v) = %v, want %v", tt.target, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,137 @@
+package local
+
+import (
+	"io"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	fdiff "github.com/go-git/go-git/v5/plumbing/format/diff"
+	"github.com/go-git/go-git/v5/utils/diff"
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// FileVersion is one side of a FileDiff
+type FileVersion struct {
+	Mode    filemode.FileMode
+	Content []byte
+}
+
+// FileDiff describes the change to a single path, where a nil From denotes
+// a new file and a nil To denotes a deleted file
+type FileDiff struct {
+	Path string
+	From *FileVersion
+	To   *FileVersion
+}
+
+// WritePatch writes the given file diffs to w in unified diff format
+func WritePatch(w io.Writer, diffs []FileDiff) error {
+	p := patch{}
+	for _, d := range diffs {
+		p.filePatches = append(p.filePatches, newFilePatch(d))
+	}
+	return fdiff.NewUnifiedEncoder(w, fdiff.DefaultContextLines).Encode(p)
+}
+
+type patch struct {
+	filePatches []fdiff.FilePatch
+}
+
+func (p patch) FilePatches() []fdiff.FilePatch {
+	return p.filePatches
+}
+
+func (p patch) Message() string {
+	return ""
+}
+
+type filePatch struct {
+	from, to fdiff.File
+	binary   bool
+	chunks   []fdiff.Chunk
+}
+
+func newFilePatch(d FileDiff) *filePatch {
+	fp := &filePatch{}
+	var fromContent, toContent []byte
+
+	if d.From != nil {
+		fp.from = newFile(d.Path, d.From)
+		fromContent = d.From.Content
+	}
+	if d.To != nil {
+		fp.to = newFile(d.Path, d.To)
+		toContent = d.To.Content
+	}
+
+	if IsBinary(fromContent) || IsBinary(toContent) {
+		fp.binary = true
+		return fp
+	}
+
+	for _, d := range diff.Do(string(fromContent), string(toContent)) {
+		var op fdiff.Operation
+		switch d.Type {
+		case diffmatchpatch.DiffEqual:
+			op = fdiff.Equal
+		case diffmatchpatch.DiffDelete:
+			op = fdiff.Delete
+		case diffmatchpatch.DiffInsert:
+			op = fdiff.Add
+		}
+		fp.chunks = append(fp.chunks, chunk{content: d.Text, op: op})
+	}
+
+	return fp
+}
+
+func (fp *filePatch) IsBinary() bool {
+	return fp.binary
+}
+
+func (fp *filePatch) Files() (from fdiff.File, to fdiff.File) {
+	return fp.from, fp.to
+}
+
+func (fp *filePatch) Chunks() []fdiff.Chunk {
+	return fp.chunks
+}
+
+type file struct {
+	path string
+	mode filemode.FileMode
+	hash plumbing.Hash
+}
+
+func newFile(path string, v *FileVersion) *file {
+	return &file{
+		path: path,
+		mode: v.Mode,
+		hash: plumbing.ComputeHash(plumbing.BlobObject, v.Content),
+	}
+}
+
+func (f *file) Hash() plumbing.Hash {
+	return f.hash
+}
+
+func (f *file) Mode() filemode.FileMode {
+	return f.mode
+}
+
+func (f *file) Path() string {
+	return f.path
+}
+
+type chunk struct {
+	content string
+	op      fdiff.Operation
+}
+
+func (c chunk) Content() string {
+	return c.content
+}
+
+func (c chunk) Type() fdiff.Operation {
+	return c.op
+}
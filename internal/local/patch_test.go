@@ -0,0 +1,83 @@
+package local
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+)
+
+func TestWritePatch(t *testing.T) {
+	tests := []struct {
+		name string
+		diff FileDiff
+		want string
+	}{
+		{
+			name: "New file",
+			diff: FileDiff{
+				Path: "new.txt",
+				To:   &FileVersion{Mode: filemode.Regular, Content: []byte("hello\n")},
+			},
+			want: "diff --git a/new.txt b/new.txt\n" +
+				"new file mode 100644\n" +
+				"index 0000000000000000000000000000000000000000..ce013625030ba8dba906f756967f9e9ca394464a\n" +
+				"--- /dev/null\n" +
+				"+++ b/new.txt\n" +
+				"@@ -0,0 +1 @@\n" +
+				"+hello\n",
+		},
+		{
+			name: "Deleted file",
+			diff: FileDiff{
+				Path: "old.txt",
+				From: &FileVersion{Mode: filemode.Regular, Content: []byte("hello\n")},
+			},
+			want: "diff --git a/old.txt b/old.txt\n" +
+				"deleted file mode 100644\n" +
+				"index ce013625030ba8dba906f756967f9e9ca394464a..0000000000000000000000000000000000000000\n" +
+				"--- a/old.txt\n" +
+				"+++ /dev/null\n" +
+				"@@ -1 +0,0 @@\n" +
+				"-hello\n",
+		},
+		{
+			name: "Modified file",
+			diff: FileDiff{
+				Path: "file.txt",
+				From: &FileVersion{Mode: filemode.Regular, Content: []byte("hello\n")},
+				To:   &FileVersion{Mode: filemode.Regular, Content: []byte("world\n")},
+			},
+			want: "diff --git a/file.txt b/file.txt\n" +
+				"index ce013625030ba8dba906f756967f9e9ca394464a..cc628ccd10742baea8241c5924df992b5c019f71 100644\n" +
+				"--- a/file.txt\n" +
+				"+++ b/file.txt\n" +
+				"@@ -1 +1 @@\n" +
+				"-hello\n" +
+				"+world\n",
+		},
+		{
+			name: "Binary file",
+			diff: FileDiff{
+				Path: "file.bin",
+				From: &FileVersion{Mode: filemode.Regular, Content: []byte("\x00\x01")},
+				To:   &FileVersion{Mode: filemode.Regular, Content: []byte("\x00\x02")},
+			},
+			want: "diff --git a/file.bin b/file.bin\n" +
+				"index bdc955b7b2e610ad5a72302b139a2e6cb325519a..8835708590a9afa236e1bbad18df9d23de82ccd3 100644\n" +
+				"Binary files a/file.bin and b/file.bin differ\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got bytes.Buffer
+			if err := WritePatch(&got, []FileDiff{tt.diff}); err != nil {
+				t.Fatalf("WritePatch() error = %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("WritePatch() =\n%s\nwant\n%s", got.String(), tt.want)
+			}
+		})
+	}
+}
@@ -1,10 +1,12 @@
 package local
 
 import (
+	"slices"
 	"strings"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
 	giturls "github.com/whilp/git-urls"
 )
 
@@ -71,6 +73,56 @@ func (r *Repository) Status() (status git.Status, err error) {
 	return worktree.Status()
 }
 
+// Root returns the root directory of the repository's work tree
+func (r *Repository) Root() (root string, err error) {
+	worktree, err := r.Repository.Worktree()
+	if err != nil {
+		return "", err
+	}
+	return worktree.Filesystem.Root(), nil
+}
+
+// IgnoreMatcher returns a matcher of the work tree's gitignore rules, as applied by status
+func (r *Repository) IgnoreMatcher() (matcher gitignore.Matcher, err error) {
+	worktree, err := r.Repository.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	patterns, err := gitignore.ReadPatterns(worktree.Filesystem, nil)
+	if err != nil {
+		return nil, err
+	}
+	return gitignore.NewMatcher(append(patterns, worktree.Excludes...)), nil
+}
+
+// StatusChanges splits the paths reported by status, as by `git status --porcelain`,
+// into those modified or added (including untracked) and those deleted
+func StatusChanges(status git.Status) (updates []string, deletions []string) {
+	for path, s := range status {
+		switch {
+		case s.Worktree == git.Deleted || (s.Staging == git.Deleted && s.Worktree != git.Untracked):
+			deletions = append(deletions, path)
+		case s.Worktree != git.Unmodified || s.Staging != git.Unmodified:
+			updates = append(updates, path)
+		}
+	}
+	slices.Sort(updates)
+	slices.Sort(deletions)
+	return
+}
+
+// UnpushedChanges returns the paths with untracked or unstaged changes, per status,
+// other than those being pushed
+func UnpushedChanges(status git.Status, pushed map[string]bool) (paths []string) {
+	for path, s := range status {
+		if s.Worktree != git.Unmodified && !pushed[path] {
+			paths = append(paths, path)
+		}
+	}
+	slices.Sort(paths)
+	return
+}
+
 func parseRemote(remote string) (owner string, repo string, ok bool) {
 	url, err := giturls.Parse(remote)
 	if err != nil {
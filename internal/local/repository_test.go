@@ -0,0 +1,81 @@
+package local
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+)
+
+func TestStatusChanges(t *testing.T) {
+	status := git.Status{
+		"modified.txt":         {Staging: git.Unmodified, Worktree: git.Modified},
+		"staged.txt":           {Staging: git.Modified, Worktree: git.Unmodified},
+		"added.txt":            {Staging: git.Added, Worktree: git.Unmodified},
+		"dir/untracked.txt":    {Staging: git.Untracked, Worktree: git.Untracked},
+		"deleted.txt":          {Staging: git.Unmodified, Worktree: git.Deleted},
+		"staged-deleted.txt":   {Staging: git.Deleted, Worktree: git.Unmodified},
+		"deleted-recreated.md": {Staging: git.Deleted, Worktree: git.Untracked},
+		"unmodified.txt":       {Staging: git.Unmodified, Worktree: git.Unmodified},
+	}
+
+	wantUpdates := []string{"added.txt", "deleted-recreated.md", "dir/untracked.txt", "modified.txt", "staged.txt"}
+	wantDeletions := []string{"deleted.txt", "staged-deleted.txt"}
+
+	updates, deletions := StatusChanges(status)
+	if !slices.Equal(updates, wantUpdates) {
+		t.Errorf("StatusChanges() updates = %v, want %v", updates, wantUpdates)
+	}
+	if !slices.Equal(deletions, wantDeletions) {
+		t.Errorf("StatusChanges() deletions = %v, want %v", deletions, wantDeletions)
+	}
+}
+
+func TestUnpushedChanges(t *testing.T) {
+	status := git.Status{
+		"modified.txt":      {Staging: git.Unmodified, Worktree: git.Modified},
+		"staged.txt":        {Staging: git.Modified, Worktree: git.Unmodified},
+		"dir/untracked.txt": {Staging: git.Untracked, Worktree: git.Untracked},
+		"deleted.txt":       {Staging: git.Unmodified, Worktree: git.Deleted},
+		"pushed.txt":        {Staging: git.Unmodified, Worktree: git.Modified},
+	}
+	pushed := map[string]bool{"pushed.txt": true}
+
+	want := []string{"deleted.txt", "dir/untracked.txt", "modified.txt"}
+	if got := UnpushedChanges(status, pushed); !slices.Equal(got, want) {
+		t.Errorf("UnpushedChanges() = %v, want %v", got, want)
+	}
+}
+
+func TestIgnoreMatcher(t *testing.T) {
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("*.log\nbuild/\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	matcher, err := (&Repository{Repository: repo}).IgnoreMatcher()
+	if err != nil {
+		t.Fatalf("IgnoreMatcher() unexpected error: %v", err)
+	}
+
+	tests := []struct {
+		path []string
+		want bool
+	}{
+		{path: []string{"debug.log"}, want: true},
+		{path: []string{"dir", "trace.log"}, want: true},
+		{path: []string{"build", "app"}, want: true},
+		{path: []string{"config", "app.yaml"}, want: false},
+	}
+	for _, tt := range tests {
+		if got := matcher.Match(tt.path, false); got != tt.want {
+			t.Errorf("Match(%v) = %v; expected %v", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package local
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// SecretMatch locates a possible secret found by a detector
+type SecretMatch struct {
+	Detector string
+	Line     int
+	Text     string
+}
+
+type secretDetector struct {
+	name    string
+	pattern *regexp.Regexp
+	// entropy, if positive, is the minimum Shannon entropy (bits per character) of a match
+	entropy float64
+}
+
+var secretDetectors = []secretDetector{
+	{name: "aws-access-key-id", pattern: regexp.MustCompile(`\b(?:AKIA|ASIA|ABIA|ACCA)[0-9A-Z]{16}\b`)},
+	{name: "aws-secret-access-key", pattern: regexp.MustCompile(`(?i)aws_?secret_?(?:access_?)?key["']?\s*[:=]\s*["']?[A-Za-z0-9/+]{40}\b`)},
+	{name: "github-token", pattern: regexp.MustCompile(`\b(?:gh[pousr]|github_pat)_[A-Za-z0-9_]{36,}\b`)},
+	{name: "private-key", pattern: regexp.MustCompile(`-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY(?: BLOCK)?-----`)},
+	{name: "high-entropy-string", pattern: regexp.MustCompile(`[A-Za-z0-9+/_=-]{24,}`), entropy: 4.5},
+}
+
+// ParseSecretsAllowlist parses allowlist content: one regular expression per line,
+// matching the text of known false positives, ignoring blank lines and # comments
+func ParseSecretsAllowlist(content []byte) (allowlist []*regexp.Regexp, err error) {
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		re, err := regexp.Compile(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		allowlist = append(allowlist, re)
+	}
+	return allowlist, scanner.Err()
+}
+
+// ScanSecrets returns the possible secrets found in text content, by line,
+// except those matched by an allowlist expression
+func ScanSecrets(content []byte, allowlist []*regexp.Regexp) (matches []SecretMatch) {
+	for n, line := range strings.Split(string(content), "\n") {
+		lineMatched := false
+		for _, detector := range secretDetectors {
+			for _, text := range detector.pattern.FindAllString(line, -1) {
+				// entropy detectors only catch what specific detectors did not
+				if detector.entropy > 0 && (lineMatched || !isMixedAlphanumeric(text) || shannonEntropy(text) < detector.entropy) {
+					continue
+				}
+				if isAllowed(text, allowlist) {
+					continue
+				}
+				matches = append(matches, SecretMatch{Detector: detector.name, Line: n + 1, Text: text})
+				lineMatched = true
+			}
+		}
+	}
+	return matches
+}
+
+func isAllowed(text string, allowlist []*regexp.Regexp) bool {
+	for _, re := range allowlist {
+		if re.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+// isMixedAlphanumeric reports whether s contains upper and lower case letters and digits,
+// as random tokens do, unlike identifiers, paths and words
+func isMixedAlphanumeric(s string) bool {
+	return strings.ContainsAny(s, "0123456789") &&
+		strings.ContainsAny(s, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") &&
+		strings.ContainsAny(s, "abcdefghijklmnopqrstuvwxyz")
+}
+
+func shannonEntropy(s string) (entropy float64) {
+	counts := map[rune]int{}
+	for _, r := range s {
+		counts[r]++
+	}
+	length := float64(len(s))
+	for _, count := range counts {
+		p := float64(count) / length
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
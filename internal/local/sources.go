@@ -0,0 +1,145 @@
+package local
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StdinSource is the file-spec source reading content from standard input
+const StdinSource = "-"
+
+// IsURLSource reports whether source is an HTTP(S) URL to fetch content from
+func IsURLSource(source string) bool {
+	return strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "http://")
+}
+
+// defaultFetchClient fetches URL sources if no Client is set, never waiting indefinitely
+var defaultFetchClient = &http.Client{Timeout: time.Minute}
+
+// Sources loads the content of file-spec sources: local files (relative to Root, if set),
+// standard input or HTTP(S) URLs. Stdin and URL content is buffered in memory, up to
+// MaxSize bytes per source.
+type Sources struct {
+	Root        string
+	Stdin       io.Reader
+	Client      *http.Client
+	MaxSize     int64
+	Concurrency int
+
+	stdin   []byte
+	stdinOk bool
+	fetched map[string][]byte
+}
+
+// Prepare validates sources, in file-spec order, and fetches all URL sources up to Concurrency
+// at a time: only the first stdin source may read it, and the first failing fetch (again in
+// file-spec order) is reported
+func (s *Sources) Prepare(ctx context.Context, sources []string) error {
+	stdinIndex := -1
+	urls := []string{}
+	seen := map[string]bool{}
+	for i, source := range sources {
+		switch {
+		case source == StdinSource && stdinIndex != -1:
+			return fmt.Errorf("file-spec %d: stdin already read by file-spec %d", i+1, stdinIndex+1)
+		case source == StdinSource:
+			stdinIndex = i
+		case IsURLSource(source) && !seen[source]:
+			seen[source] = true
+			urls = append(urls, source)
+		}
+	}
+
+	concurrency := max(s.Concurrency, 1)
+	errs := make([]error, len(urls))
+	contents := make([][]byte, len(urls))
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, concurrency)
+	for i, url := range urls {
+		wg.Add(1)
+		slots <- struct{}{}
+		go func() {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			contents[i], errs[i] = s.fetch(ctx, url)
+		}()
+	}
+	wg.Wait()
+
+	s.fetched = make(map[string][]byte, len(urls))
+	for i, url := range urls {
+		if errs[i] != nil {
+			return fmt.Errorf("fetching %q: %w", url, errs[i])
+		}
+		s.fetched[url] = contents[i]
+	}
+	return nil
+}
+
+// Load returns the content of source, reading stdin at most once
+func (s *Sources) Load(ctx context.Context, source string) (content []byte, err error) {
+	switch {
+	case source == StdinSource:
+		if !s.stdinOk {
+			if s.stdin, err = readBounded(s.Stdin, s.MaxSize); err != nil {
+				return nil, fmt.Errorf("reading stdin: %w", err)
+			}
+			s.stdinOk = true
+		}
+		return s.stdin, nil
+	case IsURLSource(source):
+		if content, ok := s.fetched[source]; ok {
+			return content, nil
+		}
+		return s.fetch(ctx, source)
+	}
+
+	return os.ReadFile(ResolveSource(source, s.Root))
+}
+
+func (s *Sources) fetch(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := s.Client
+	if client == nil {
+		client = defaultFetchClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	case s.MaxSize > 0 && resp.ContentLength > s.MaxSize:
+		return nil, fmt.Errorf("size of %d bytes exceeds maximum of %d", resp.ContentLength, s.MaxSize)
+	}
+	return readBounded(resp.Body, s.MaxSize)
+}
+
+// readBounded reads all of r, failing if it holds more than maxSize bytes (if positive)
+func readBounded(r io.Reader, maxSize int64) ([]byte, error) {
+	if maxSize <= 0 {
+		return io.ReadAll(r)
+	}
+	content, err := io.ReadAll(io.LimitReader(r, maxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(content)) > maxSize {
+		return nil, fmt.Errorf("size exceeds maximum of %d bytes", maxSize)
+	}
+	return content, nil
+}
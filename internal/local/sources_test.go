@@ -0,0 +1,100 @@
+package local
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSources(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/small.txt":
+			w.Write([]byte("small\n"))
+		case "/large.txt":
+			w.Write(bytes.Repeat([]byte("x"), 64))
+		case "/slow.txt":
+			time.Sleep(200 * time.Millisecond)
+			w.Write([]byte("slow\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		sources []string
+		want    []string
+		wantErr string
+	}{
+		{
+			name:    "Mixed sources in order",
+			sources: []string{server.URL + "/small.txt", StdinSource, filepath.Join("testdata", "testfile.txt"), server.URL + "/small.txt"},
+			want:    []string{"small\n", "stdin\n", "test content\n", "small\n"},
+		},
+		{
+			name:    "Second stdin source",
+			sources: []string{StdinSource, "file.txt", StdinSource},
+			wantErr: "file-spec 3: stdin already read by file-spec 1",
+		},
+		{
+			name:    "Oversized fetch",
+			sources: []string{server.URL + "/small.txt", server.URL + "/large.txt"},
+			wantErr: "exceeds maximum of 32",
+		},
+		{
+			name:    "Failed fetch",
+			sources: []string{server.URL + "/missing.txt"},
+			wantErr: "unexpected status 404",
+		},
+		{
+			name:    "Timed out fetch",
+			sources: []string{server.URL + "/slow.txt"},
+			wantErr: "Client.Timeout exceeded",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			sources := &Sources{
+				Stdin:       strings.NewReader("stdin\n"),
+				Client:      &http.Client{Timeout: 50 * time.Millisecond},
+				MaxSize:     32,
+				Concurrency: 2,
+			}
+			err := sources.Prepare(ctx, tt.sources)
+			switch {
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("Prepare() error = %v; expected %q", err, tt.wantErr)
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("Prepare() unexpected error: %v", err)
+			case tt.wantErr != "":
+				return
+			}
+
+			for i, source := range tt.sources {
+				content, err := sources.Load(ctx, source)
+				if err != nil {
+					t.Fatalf("Load(%q) unexpected error: %v", source, err)
+				}
+				if string(content) != tt.want[i] {
+					t.Errorf("Load(%q) = %q; expected %q", source, content, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestReadBoundedStdin(t *testing.T) {
+	sources := &Sources{Stdin: strings.NewReader("too long for the limit"), MaxSize: 8}
+	if _, err := sources.Load(context.Background(), StdinSource); err == nil {
+		t.Error("Load(-) expected error for oversized stdin")
+	}
+}
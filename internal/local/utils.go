@@ -1,35 +1,127 @@
 package local
 
 import (
+	"bufio"
+	"bytes"
 	"fmt"
 	"os"
+	"path"
+	"path/filepath"
 	"strings"
 )
 
-// GetLocalFileContent loads the content of a file and returns the target path and its contents
-func GetLocalFileContent(arg string, separator string) (target string, content []byte, err error) {
-	var source string
+// ParseFileSpec splits a file-spec into its local source and remote target paths
+func ParseFileSpec(arg string, separator string) (source string, target string, err error) {
+	// don't split the default separator from a leading Windows drive letter (e.g. `C:\`)
+	// or the scheme and host of a URL source (e.g. `https://example.com:8443/`)
+	prefix := ""
+	if separator == ":" {
+		prefix, arg = arg[:protectedPrefixLen(arg)], arg[protectedPrefixLen(arg):]
+	}
 
 	files := strings.SplitN(arg, separator, 2)
+	files[0] = prefix + files[0]
 
 	switch {
 	case len(files) < 1:
 		err = fmt.Errorf("invalid file parameter")
-		return
 	case files[0] == "":
 		err = fmt.Errorf("no source file specified")
-		return
+	case len(files) == 1 && (files[0] == StdinSource || IsURLSource(files[0])):
+		err = fmt.Errorf("no target file specified for %q", files[0])
 	case len(files) == 1:
 		source = files[0]
 		target = files[0]
 	case files[1] == "":
 		err = fmt.Errorf("no target file specified")
-		return
 	default:
 		source = files[0]
 		target = files[1]
 	}
 
-	content, err = os.ReadFile(source)
+	return
+}
+
+// protectedPrefixLen returns the length of the leading part of arg not to split on ":"
+func protectedPrefixLen(arg string) int {
+	if hasDriveLetter(arg) {
+		return 2
+	}
+	if IsURLSource(arg) {
+		rest := arg[strings.Index(arg, "://")+3:]
+		if slash := strings.Index(rest, "/"); slash != -1 {
+			return len(arg) - len(rest) + slash
+		}
+		return len(arg)
+	}
+	return 0
+}
+
+// hasDriveLetter reports whether arg starts with a Windows drive letter followed by a path separator
+func hasDriveLetter(arg string) bool {
+	return hasDrivePrefix(arg) && len(arg) >= 3 && (arg[2] == '\\' || arg[2] == '/')
+}
+
+// hasDrivePrefix reports whether arg starts with a Windows drive letter and colon (e.g. `C:`)
+func hasDrivePrefix(arg string) bool {
+	return len(arg) >= 2 &&
+		('A' <= arg[0] && arg[0] <= 'Z' || 'a' <= arg[0] && arg[0] <= 'z') &&
+		arg[1] == ':'
+}
+
+// NormalizeTargetPath cleans a remote target path into a clean, relative POSIX path,
+// optionally converting Windows-style backslash separators
+func NormalizeTargetPath(target string, windowsPaths bool) (string, error) {
+	if windowsPaths {
+		target = strings.ReplaceAll(target, `\`, "/")
+	}
+
+	// whatever the separators, a drive letter can only be an absolute (or drive-relative) local path
+	if strings.HasPrefix(target, "/") || hasDrivePrefix(target) {
+		return "", fmt.Errorf("target path %q must be relative", target)
+	}
+
+	cleaned := path.Clean(target)
+	switch {
+	case cleaned == ".":
+		return "", fmt.Errorf("target path %q is empty", target)
+	case cleaned == ".." || strings.HasPrefix(cleaned, "../"):
+		return "", fmt.Errorf("target path %q is outside the repository", target)
+	}
+
+	return cleaned, nil
+}
+
+// ResolveSource resolves a relative local source path against root, if set
+func ResolveSource(source string, root string) string {
+	if root != "" && !filepath.IsAbs(source) {
+		return filepath.Join(root, source)
+	}
+	return source
+}
+
+// IsBinary reports whether content appears to be binary, using git's heuristic
+// of a NUL byte within the first 8000 bytes
+func IsBinary(content []byte) bool {
+	return bytes.IndexByte(content[:min(len(content), 8000)], 0) != -1
+}
+
+// ReadManifest loads a list of paths from a file, one per line, ignoring blank lines and # comments
+func ReadManifest(name string) (paths []string, err error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		paths = append(paths, line)
+	}
+	err = scanner.Err()
 	return
 }
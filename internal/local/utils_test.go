@@ -2,18 +2,211 @@ package local
 
 import (
 	"bytes"
+	"context"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 )
 
-func TestGetLocalFileContent(t *testing.T) {
+func TestParseFileSpec(t *testing.T) {
+	tests := []struct {
+		name       string
+		arg        string
+		separator  string
+		wantSource string
+		wantTarget string
+		wantErr    bool
+	}{
+		{
+			name:       "Single file",
+			arg:        "file.txt",
+			separator:  ":",
+			wantSource: "file.txt",
+			wantTarget: "file.txt",
+		},
+		{
+			name:       "Source and target",
+			arg:        "file.txt:dest/file.txt",
+			separator:  ":",
+			wantSource: "file.txt",
+			wantTarget: "dest/file.txt",
+		},
+		{
+			name:       "Windows drive letter",
+			arg:        `C:\dir\file.txt`,
+			separator:  ":",
+			wantSource: `C:\dir\file.txt`,
+			wantTarget: `C:\dir\file.txt`,
+		},
+		{
+			name:       "Windows drive letter with target",
+			arg:        `c:/dir/file.txt:dest/file.txt`,
+			separator:  ":",
+			wantSource: `c:/dir/file.txt`,
+			wantTarget: "dest/file.txt",
+		},
+		{
+			name:       "Drive-like source without path separator",
+			arg:        "c:file.txt",
+			separator:  ":",
+			wantSource: "c",
+			wantTarget: "file.txt",
+		},
+		{
+			name:       "URL source with target",
+			arg:        "https://example.com:8443/config/app.yaml:config/app.yaml",
+			separator:  ":",
+			wantSource: "https://example.com:8443/config/app.yaml",
+			wantTarget: "config/app.yaml",
+		},
+		{
+			name:      "URL source without target",
+			arg:       "https://example.com/app.yaml",
+			separator: ":",
+			wantErr:   true,
+		},
+		{
+			name:       "Stdin source with target",
+			arg:        "-:config/app.yaml",
+			separator:  ":",
+			wantSource: "-",
+			wantTarget: "config/app.yaml",
+		},
+		{
+			name:      "Stdin source without target",
+			arg:       "-",
+			separator: ":",
+			wantErr:   true,
+		},
+		{
+			name:       "Windows drive letter with custom separator",
+			arg:        `C:\dir\file.txt=>dest/file.txt`,
+			separator:  "=>",
+			wantSource: `C:\dir\file.txt`,
+			wantTarget: "dest/file.txt",
+		},
+		{
+			name:      "Missing source",
+			arg:       ":file.txt",
+			separator: ":",
+			wantErr:   true,
+		},
+		{
+			name:      "Missing target",
+			arg:       "file.txt:",
+			separator: ":",
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotSource, gotTarget, err := ParseFileSpec(tt.arg, tt.separator)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseFileSpec() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if gotSource != tt.wantSource {
+				t.Errorf("ParseFileSpec() gotSource = %v, want %v", gotSource, tt.wantSource)
+			}
+			if gotTarget != tt.wantTarget {
+				t.Errorf("ParseFileSpec() gotTarget = %v, want %v", gotTarget, tt.wantTarget)
+			}
+		})
+	}
+}
+
+func TestNormalizeTargetPath(t *testing.T) {
+	tests := []struct {
+		name         string
+		target       string
+		windowsPaths bool
+		want         string
+		wantErr      bool
+	}{
+		{
+			name:   "Clean path",
+			target: "config/app.yaml",
+			want:   "config/app.yaml",
+		},
+		{
+			name:   "Leading dot-slash",
+			target: "./config/app.yaml",
+			want:   "config/app.yaml",
+		},
+		{
+			name:   "Duplicate slashes and dot-dot",
+			target: "config//old/../app.yaml",
+			want:   "config/app.yaml",
+		},
+		{
+			name:   "Backslashes preserved by default",
+			target: `config\app.yaml`,
+			want:   `config\app.yaml`,
+		},
+		{
+			name:         "Backslashes converted",
+			target:       `.\config\app.yaml`,
+			windowsPaths: true,
+			want:         "config/app.yaml",
+		},
+		{
+			name:    "Absolute path",
+			target:  "/etc/passwd",
+			wantErr: true,
+		},
+		{
+			name:         "Windows absolute path",
+			target:       `C:\config\app.yaml`,
+			windowsPaths: true,
+			wantErr:      true,
+		},
+		{
+			name:    "Windows absolute path without --windows-paths",
+			target:  `C:\config\app.yaml`,
+			wantErr: true,
+		},
+		{
+			name:    "Drive letter with forward slash",
+			target:  "C:/x",
+			wantErr: true,
+		},
+		{
+			name:    "Escaping path",
+			target:  "config/../../app.yaml",
+			wantErr: true,
+		},
+		{
+			name:    "Empty path",
+			target:  "./",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeTargetPath(tt.target, tt.windowsPaths)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NormalizeTargetPath() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeTargetPath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadFileSpec(t *testing.T) {
 	testFilePath := filepath.Join("testdata", "testfile.txt")
+	absTestFilePath, _ := filepath.Abs(testFilePath)
 	testFileContent := []byte("test content\n")
 	tests := []struct {
 		name        string
 		arg         string
 		separator   string
+		root        string
 		wantTarget  string
 		wantContent []byte
 		wantErr     bool
@@ -57,20 +250,111 @@ func TestGetLocalFileContent(t *testing.T) {
 			wantTarget:  "destfile.txt",
 			wantContent: testFileContent,
 		},
+		{
+			name:        "Content root",
+			arg:         "testfile.txt",
+			separator:   ":",
+			root:        "testdata",
+			wantTarget:  "testfile.txt",
+			wantContent: testFileContent,
+		},
+		{
+			name:        "Absolute source outside content root",
+			arg:         absTestFilePath + "=>testfile.txt",
+			separator:   "=>",
+			root:        "elsewhere",
+			wantTarget:  "testfile.txt",
+			wantContent: testFileContent,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			gotTarget, gotContent, err := GetLocalFileContent(tt.arg, tt.separator)
+			source, gotTarget, err := ParseFileSpec(tt.arg, tt.separator)
+			var gotContent []byte
+			if err == nil {
+				sources := &Sources{Root: tt.root}
+				gotContent, err = sources.Load(context.Background(), source)
+			}
 			if (err != nil) != tt.wantErr {
-				t.Errorf("GetLocalFileContent() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("Load() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 			if gotTarget != tt.wantTarget {
-				t.Errorf("GetLocalFileContent() gotTarget = %v, want %v", gotTarget, tt.wantTarget)
+				t.Errorf("ParseFileSpec() gotTarget = %v, want %v", gotTarget, tt.wantTarget)
 			}
 			if !bytes.Equal(gotContent, tt.wantContent) {
-				t.Errorf("GetLocalFileContent() gotContent = %v, want %v", gotContent, tt.wantContent)
+				t.Errorf("Load() gotContent = %v, want %v", gotContent, tt.wantContent)
+			}
+		})
+	}
+}
+
+func TestIsBinary(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []byte
+		want    bool
+	}{
+		{
+			name:    "Empty content",
+			content: []byte{},
+			want:    false,
+		},
+		{
+			name:    "Text content",
+			content: []byte("key: value\n"),
+			want:    false,
+		},
+		{
+			name:    "NUL byte",
+			content: []byte("PNG\x00\x01"),
+			want:    true,
+		},
+		{
+			name:    "NUL byte beyond heuristic window",
+			content: append(bytes.Repeat([]byte("a"), 8000), 0),
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsBinary(tt.content); got != tt.want {
+				t.Errorf("IsBinary() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadManifest(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      string
+		wantPaths []string
+		wantErr   bool
+	}{
+		{
+			name:      "Manifest with comments and blank lines",
+			path:      filepath.Join("testdata", "manifest.txt"),
+			wantPaths: []string{"config/app.yaml", "config/old.yaml"},
+		},
+		{
+			name:    "Missing manifest",
+			path:    filepath.Join("testdata", "missing.txt"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotPaths, err := ReadManifest(tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ReadManifest() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !slices.Equal(gotPaths, tt.wantPaths) {
+				t.Errorf("ReadManifest() gotPaths = %v, want %v", gotPaths, tt.wantPaths)
 			}
 		})
 	}
@@ -0,0 +1,51 @@
+package local
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ValidateJSON returns an error, locating any syntax error by line and column,
+// unless content is a single well-formed JSON value
+func ValidateJSON(content []byte) error {
+	var v interface{}
+	err := json.Unmarshal(content, &v)
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		// the offset follows the offending byte
+		line, column := position(content, syntaxErr.Offset-1)
+		return fmt.Errorf("line %d, column %d: %w", line, column, err)
+	}
+	return err
+}
+
+// ValidateYAML returns an error, whose message includes the line of any syntax error,
+// unless every document in content is well-formed YAML
+func ValidateYAML(content []byte) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var v yaml.Node
+		err := decoder.Decode(&v)
+		switch {
+		case errors.Is(err, io.EOF):
+			return nil
+		case err != nil:
+			return err
+		}
+	}
+}
+
+// position converts a byte offset into content into 1-based line and column numbers
+func position(content []byte, offset int64) (line int, column int) {
+	offset = max(0, min(offset, int64(len(content))))
+	before := content[:offset]
+	line = bytes.Count(before, []byte("\n")) + 1
+	column = len(before) - bytes.LastIndexByte(before, '\n')
+	return
+}
@@ -0,0 +1,89 @@
+package local
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "Valid object",
+			content: "{\n  \"key\": [1, 2, 3]\n}\n",
+		},
+		{
+			name:    "Trailing comma",
+			content: "{\n  \"key\": 1,\n}\n",
+			wantErr: "line 3, column 1",
+		},
+		{
+			name:    "Unterminated",
+			content: "{\"key\": ",
+			wantErr: "unexpected end of JSON input",
+		},
+		{
+			name:    "Multiple values",
+			content: "{} {}",
+			wantErr: "line 1, column 4",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateJSON([]byte(tt.content))
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("ValidateJSON() unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("ValidateJSON() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateYAML(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "Valid mapping",
+			content: "key:\n  - 1\n  - 2\n",
+		},
+		{
+			name:    "Empty",
+			content: "",
+		},
+		{
+			name:    "Multiple documents",
+			content: "a: 1\n---\nb: 2\n",
+		},
+		{
+			name:    "Bad indentation",
+			content: "key:\n  a: 1\n b: 2\n",
+			wantErr: "did not find expected key",
+		},
+		{
+			name:    "Invalid second document",
+			content: "a: 1\n---\nb: [\n",
+			wantErr: "line",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateYAML([]byte(tt.content))
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("ValidateYAML() unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("ValidateYAML() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
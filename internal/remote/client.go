@@ -3,10 +3,13 @@ package remote
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/apex/log"
 	"github.com/google/go-github/v64/github"
@@ -14,28 +17,54 @@ import (
 	"golang.org/x/oauth2"
 )
 
+// ErrRepositoryNotFound is returned when a repository does not exist or is not visible to the token
+var ErrRepositoryNotFound = errors.New("repository not found")
+
+// ErrMergeConflict is returned when a branch cannot be merged without conflicts
+var ErrMergeConflict = errors.New("merge conflict")
+
+// ErrRepositoryArchived is returned when a repository is archived, and so read-only
+var ErrRepositoryArchived = errors.New("archived and read-only")
+
+// ErrRefNotFound is returned when a ref does not exist
+var ErrRefNotFound = errors.New("does not exist")
+
+// ErrTooManyChangedFiles is returned when a comparison exceeds the file limit of the compare API
+var ErrTooManyChangedFiles = errors.New("too many changed files to compare")
+
 type TokenClient struct {
 	Context context.Context
 	V3      *github.Client
 	V4      *githubv4.Client
+	token   string
+	keyer   *idempotencyKeyer
+	// plain shares the transport stack of V3 and V4, without their token authentication
+	plain   *http.Client
+	retries *atomic.Int64
 }
 
 type BranchInfo struct {
-	Name   string
-	Commit githubv4.GitObjectID
+	Name                     string
+	Commit                   githubv4.GitObjectID
+	RequiresCommitSignatures bool
+	Locked                   bool
 }
 
 type RepositoryInfo struct {
-	NodeID        string
-	IsEmpty       bool
-	DefaultBranch BranchInfo
-	TargetBranch  BranchInfo
+	NodeID           string
+	IsEmpty          bool
+	IsArchived       bool
+	ViewerPermission githubv4.RepositoryPermission
+	DefaultBranch    BranchInfo
+	TargetBranch     BranchInfo
 }
 
 type RepositoryInfoQuery struct {
 	Repository struct {
 		Id               githubv4.String
 		IsEmpty          githubv4.Boolean
+		IsArchived       githubv4.Boolean
+		ViewerPermission githubv4.RepositoryPermission
 		DefaultBranchRef struct {
 			Name   githubv4.String
 			Target struct {
@@ -46,10 +75,67 @@ type RepositoryInfoQuery struct {
 			Target struct {
 				Oid githubv4.GitObjectID
 			}
+			BranchProtectionRule *struct {
+				RequiresCommitSignatures githubv4.Boolean
+				LockBranch               githubv4.Boolean
+			}
 		} `graphql:"ref(qualifiedName: $branch)"`
 	} `graphql:"repository(owner: $owner, name: $repo)"`
 }
 
+type ViewerV4Query struct {
+	Viewer struct {
+		Login      githubv4.String
+		DatabaseId githubv4.Int
+		Name       githubv4.String
+		Email      githubv4.String
+	}
+}
+
+type OwnerTypeV4Query struct {
+	RepositoryOwner *struct {
+		Typename githubv4.String `graphql:"__typename"`
+	} `graphql:"repositoryOwner(login: $owner)"`
+}
+
+type SearchCountV4Query struct {
+	Search struct {
+		IssueCount githubv4.Int
+	} `graphql:"search(query: $query, type: ISSUE, first: 1)"`
+}
+
+type OpenPullRequestV4Query struct {
+	Repository struct {
+		PullRequests struct {
+			Nodes []struct {
+				Permalink githubv4.URI
+			}
+		} `graphql:"pullRequests(headRefName: $head, baseRefName: $base, states: OPEN, first: 1)"`
+	} `graphql:"repository(owner: $owner, name: $repo)"`
+}
+
+type PullRequestHead struct {
+	State  githubv4.PullRequestState
+	Owner  string
+	Repo   string
+	Branch string
+}
+
+type PullRequestHeadV4Query struct {
+	Repository struct {
+		PullRequest *struct {
+			State          githubv4.PullRequestState
+			HeadRefName    githubv4.String
+			HeadRepository *struct {
+				Name  githubv4.String
+				Owner struct {
+					Login githubv4.String
+				}
+			}
+		} `graphql:"pullRequest(number: $number)"`
+	} `graphql:"repository(owner: $owner, name: $repo)"`
+}
+
 type FileHashV4Query struct {
 	Repository struct {
 		Object struct {
@@ -62,6 +148,60 @@ type FileHashV4Query struct {
 	} `graphql:"repository(owner: $owner, name: $repo)"`
 }
 
+type TreeEntry struct {
+	Path string
+	Mode int
+	Oid  string
+}
+
+type TreeV4Query struct {
+	Repository struct {
+		Object struct {
+			Tree struct {
+				Entries []struct {
+					Path githubv4.String
+					Mode githubv4.Int
+					Type githubv4.String
+					Oid  githubv4.GitObjectID
+				}
+			} `graphql:"... on Tree"`
+		} `graphql:"object(expression: $expression)"`
+	} `graphql:"repository(owner: $owner, name: $repo)"`
+}
+
+type FileContentV4Query struct {
+	Repository struct {
+		Object struct {
+			Blob struct {
+				Oid         githubv4.GitObjectID
+				IsBinary    *githubv4.Boolean
+				IsTruncated githubv4.Boolean
+				Text        *githubv4.String
+			} `graphql:"... on Blob"`
+		} `graphql:"object(expression: $expression)"`
+	} `graphql:"repository(owner: $owner, name: $repo)"`
+}
+
+type CommittedDateV4Query struct {
+	Repository struct {
+		Object struct {
+			Commit struct {
+				CommittedDate githubv4.DateTime
+			} `graphql:"... on Commit"`
+		} `graphql:"object(oid: $oid)"`
+	} `graphql:"repository(owner: $owner, name: $repo)"`
+}
+
+type CommitUrlV4Query struct {
+	Repository struct {
+		Object struct {
+			Commit struct {
+				Url githubv4.URI
+			} `graphql:"... on Commit"`
+		} `graphql:"object(oid: $oid)"`
+	} `graphql:"repository(owner: $owner, name: $repo)"`
+}
+
 type RefOidV4Query struct {
 	Repository struct {
 		Ref struct {
@@ -99,27 +239,50 @@ type CreatePullRequestV4Mutation struct {
 	} `graphql:"createPullRequest(input: $input)"`
 }
 
-func NewTokenClient(ctx context.Context, token string) (client *TokenClient, err error) {
+func NewTokenClient(ctx context.Context, token string, opts ...ClientOption) (client *TokenClient, err error) {
 	token, err = ResolveToken(token)
 	if err != nil {
 		return
 	}
 
+	options := clientOptions{idempotencyKeyer: &idempotencyKeyer{}, retries: &atomic.Int64{}}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	src := oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: token},
 	)
 
-	httpClient := oauth2.NewClient(ctx, src)
+	// authenticate above the shared stack, so that plain requests (e.g. LFS transfers to
+	// storage hosts) go through the same retries, host limits and SSO detection, tokenless
+	transport := newTransport(http.DefaultTransport, options)
+	httpClient := &http.Client{Transport: &oauth2.Transport{Source: src, Base: transport}}
 
 	client = &TokenClient{
 		Context: ctx,
-		V3:      github.NewClient(httpClient),
+		V3:      github.NewClient(&http.Client{Transport: &apiVersioner{transport: httpClient.Transport, version: options.apiVersion}}),
 		V4:      githubv4.NewClient(httpClient),
+		token:   token,
+		keyer:   options.idempotencyKeyer,
+		plain:   &http.Client{Transport: transport},
+		retries: options.retries,
 	}
 
 	return client, nil
 }
 
+// Retries returns the number of requests retried so far
+func (c *TokenClient) Retries() int {
+	return int(c.retries.Load())
+}
+
+// SetIdempotencyKey sets the Idempotency-Key header base for subsequent mutating requests,
+// which are suffixed by their ordinal ("<key>-1", "<key>-2", ...)
+func (c *TokenClient) SetIdempotencyKey(key string) {
+	c.keyer.setKey(key)
+}
+
 func ResolveToken(tokenVar string) (token string, err error) {
 	token = tokenVar
 
@@ -163,6 +326,30 @@ func (c *TokenClient) GetCommitSHA(ctx context.Context, owner string, repo strin
 	return &commitSHA, resp, nil
 }
 
+// GetChangedFilesV3 returns the set of paths changed between two commits,
+// including the previous paths of renamed files
+func (c *TokenClient) GetChangedFilesV3(ctx context.Context, owner string, repo string, base string, head string) (paths map[string]bool, err error) {
+	comparison, _, err := c.V3.Repositories.CompareCommits(ctx, owner, repo, base, head, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// the compare API lists at most 300 files
+	if len(comparison.Files) >= 300 {
+		return nil, ErrTooManyChangedFiles
+	}
+
+	paths = make(map[string]bool, len(comparison.Files))
+	for _, file := range comparison.Files {
+		paths[file.GetFilename()] = true
+		if previous := file.GetPreviousFilename(); previous != "" {
+			paths[previous] = true
+		}
+	}
+
+	return
+}
+
 func (c *TokenClient) GetRepositoryInfo(owner string, repo string, branch string) (repository RepositoryInfo, err error) {
 	var query RepositoryInfoQuery
 	variables := map[string]interface{}{
@@ -172,12 +359,17 @@ func (c *TokenClient) GetRepositoryInfo(owner string, repo string, branch string
 	}
 	err = c.V4.Query(c.Context, &query, variables)
 	if err != nil {
+		if strings.Contains(err.Error(), "Could not resolve to a Repository") {
+			err = fmt.Errorf("%w: %s", ErrRepositoryNotFound, err)
+		}
 		return
 	}
 
 	repository = RepositoryInfo{
-		NodeID:  string(query.Repository.Id),
-		IsEmpty: bool(query.Repository.IsEmpty),
+		NodeID:           string(query.Repository.Id),
+		IsEmpty:          bool(query.Repository.IsEmpty),
+		IsArchived:       bool(query.Repository.IsArchived),
+		ViewerPermission: query.Repository.ViewerPermission,
 		DefaultBranch: BranchInfo{
 			Name:   string(query.Repository.DefaultBranchRef.Name),
 			Commit: query.Repository.DefaultBranchRef.Target.Oid,
@@ -189,6 +381,78 @@ func (c *TokenClient) GetRepositoryInfo(owner string, repo string, branch string
 			Name:   branch,
 			Commit: query.Repository.Ref.Target.Oid,
 		}
+		if rule := query.Repository.Ref.BranchProtectionRule; rule != nil {
+			repository.TargetBranch.RequiresCommitSignatures = bool(rule.RequiresCommitSignatures)
+			repository.TargetBranch.Locked = bool(rule.LockBranch)
+		}
+	}
+
+	// fail early, rather than with an unclear permission error on the first mutation
+	if repository.IsArchived {
+		err = fmt.Errorf("repository %s/%s is %w", owner, repo, ErrRepositoryArchived)
+	}
+
+	return
+}
+
+// GetViewerLoginV4 returns the login of the token owner
+func (c *TokenClient) GetViewerLoginV4() (login string, err error) {
+	var query ViewerV4Query
+	err = c.V4.Query(c.Context, &query, nil)
+	return string(query.Viewer.Login), err
+}
+
+// GetViewerIdentityV4 returns a commit identity for the token owner, falling back
+// to login and noreply address where name or email are not public
+func (c *TokenClient) GetViewerIdentityV4() (identity *github.CommitAuthor, err error) {
+	var query ViewerV4Query
+	err = c.V4.Query(c.Context, &query, nil)
+	if err != nil {
+		return
+	}
+
+	viewer := query.Viewer
+	identity = &github.CommitAuthor{
+		Name:  github.String(string(viewer.Name)),
+		Email: github.String(string(viewer.Email)),
+	}
+	if viewer.Name == "" {
+		identity.Name = github.String(string(viewer.Login))
+	}
+	if viewer.Email == "" {
+		log.Infof("viewer %q has no public email: using noreply address", viewer.Login)
+		identity.Email = github.String(fmt.Sprintf("%d+%s@users.noreply.github.com", viewer.DatabaseId, viewer.Login))
+	}
+
+	return
+}
+
+// GetPullRequestHeadV4 resolves the head repository and branch of a pull request, which may be a fork
+func (c *TokenClient) GetPullRequestHeadV4(owner string, repo string, number int) (head PullRequestHead, err error) {
+	var query PullRequestHeadV4Query
+	variables := map[string]interface{}{
+		"owner":  githubv4.String(owner),
+		"repo":   githubv4.String(repo),
+		"number": githubv4.Int(number),
+	}
+	err = c.V4.Query(c.Context, &query, variables)
+	if err != nil {
+		return
+	}
+
+	pullRequest := query.Repository.PullRequest
+	switch {
+	case pullRequest == nil:
+		err = fmt.Errorf("pull request #%d does not exist", number)
+	case pullRequest.HeadRepository == nil:
+		err = fmt.Errorf("head repository of pull request #%d no longer exists", number)
+	default:
+		head = PullRequestHead{
+			State:  pullRequest.State,
+			Owner:  string(pullRequest.HeadRepository.Owner.Login),
+			Repo:   string(pullRequest.HeadRepository.Name),
+			Branch: string(pullRequest.HeadRefName),
+		}
 	}
 
 	return
@@ -209,6 +473,72 @@ func (c *TokenClient) GetFileHashV4(owner string, repo string, branch string, pa
 	return
 }
 
+// ListTreeV4 recursively lists the blobs found under path on the given ref,
+// returning an empty list if path does not exist
+func (c *TokenClient) ListTreeV4(owner string, repo string, ref string, path string) (entries []TreeEntry, err error) {
+	var query TreeV4Query
+	variables := map[string]interface{}{
+		"owner":      githubv4.String(owner),
+		"repo":       githubv4.String(repo),
+		"expression": githubv4.String(fmt.Sprintf("%s:%s", ref, path)),
+	}
+	err = c.V4.Query(c.Context, &query, variables)
+	if err != nil {
+		return
+	}
+
+	for _, entry := range query.Repository.Object.Tree.Entries {
+		switch entry.Type {
+		case "blob":
+			entries = append(entries, TreeEntry{
+				Path: string(entry.Path),
+				Mode: int(entry.Mode),
+				Oid:  string(entry.Oid),
+			})
+		case "tree":
+			subEntries, err := c.ListTreeV4(owner, repo, ref, string(entry.Path))
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, subEntries...)
+		}
+	}
+
+	return
+}
+
+// GetFileContentV4 retrieves the content of the file at path on the given ref,
+// falling back to the V3 API for binary or truncated content
+func (c *TokenClient) GetFileContentV4(owner string, repo string, ref string, path string) (content []byte, err error) {
+	var query FileContentV4Query
+	variables := map[string]interface{}{
+		"owner":      githubv4.String(owner),
+		"repo":       githubv4.String(repo),
+		"expression": githubv4.String(fmt.Sprintf("%s:%s", ref, path)),
+	}
+	err = c.V4.Query(c.Context, &query, variables)
+	if err != nil {
+		return
+	}
+
+	blob := query.Repository.Object.Blob
+	switch {
+	case blob.Oid == "":
+		return nil, fmt.Errorf("file %q does not exist on %q", path, ref)
+	case blob.Text == nil || bool(blob.IsTruncated) || (blob.IsBinary != nil && bool(*blob.IsBinary)):
+		content, _, err = c.V3.Git.GetBlobRaw(c.Context, owner, repo, string(blob.Oid))
+		return
+	default:
+		return []byte(*blob.Text), nil
+	}
+}
+
+// GetBlobV3 retrieves the raw content of blob oid
+func (c *TokenClient) GetBlobV3(ctx context.Context, owner string, repo string, oid string) (content []byte, err error) {
+	content, _, err = c.V3.Git.GetBlobRaw(ctx, owner, repo, oid)
+	return
+}
+
 func (c *TokenClient) GetRefOidV4(owner string, repo string, refName string) (oid githubv4.GitObjectID, err error) {
 	var query RefOidV4Query
 	variables := map[string]interface{}{
@@ -224,7 +554,7 @@ func (c *TokenClient) GetRefOidV4(owner string, repo string, refName string) (oi
 
 	oid = query.Repository.Ref.Target.Oid
 	if oid == "" {
-		err = fmt.Errorf("ref %q does not exist", refName)
+		err = fmt.Errorf("ref %q %w", refName, ErrRefNotFound)
 	}
 	return
 }
@@ -251,6 +581,328 @@ func (c *TokenClient) CreateCommitOnBranchV4(input githubv4.CreateCommitOnBranch
 	return
 }
 
+// GetOwnerTypeV4 returns the type of repository owner login: "User" or "Organization"
+func (c *TokenClient) GetOwnerTypeV4(owner string) (ownerType string, err error) {
+	var query OwnerTypeV4Query
+	variables := map[string]interface{}{
+		"owner": githubv4.String(owner),
+	}
+	err = c.V4.Query(c.Context, &query, variables)
+	if err != nil {
+		return
+	}
+	if query.RepositoryOwner == nil {
+		return "", fmt.Errorf("owner %q not found", owner)
+	}
+	return string(query.RepositoryOwner.Typename), nil
+}
+
+// CreateRepositoryV3 creates a private repository, auto-initialized with an initial commit,
+// owned by organization owner or, if not org, by the token owner
+func (c *TokenClient) CreateRepositoryV3(ctx context.Context, owner string, repo string, org bool) (url string, err error) {
+	if !org {
+		owner = ""
+	}
+	repository, _, err := c.V3.Repositories.Create(ctx, owner, &github.Repository{
+		Name:     github.String(repo),
+		Private:  github.Bool(true),
+		AutoInit: github.Bool(true),
+	})
+	if err != nil {
+		return "", err
+	}
+	return repository.GetHTMLURL(), nil
+}
+
+// CreateRepositoryFromTemplateV3 creates a private repository owned by owner from template repository
+// templateOwner/templateRepo, including its default branch only. Note that GitHub populates the new
+// repository asynchronously.
+func (c *TokenClient) CreateRepositoryFromTemplateV3(ctx context.Context, templateOwner string, templateRepo string, owner string, repo string) (url string, err error) {
+	repository, _, err := c.V3.Repositories.CreateFromTemplate(ctx, templateOwner, templateRepo, &github.TemplateRepoRequest{
+		Name:    github.String(repo),
+		Owner:   github.String(owner),
+		Private: github.Bool(true),
+	})
+	if err != nil {
+		return "", err
+	}
+	return repository.GetHTMLURL(), nil
+}
+
+// GetDefaultBranchV3 returns the configured default branch of a repository, which may be unborn
+func (c *TokenClient) GetDefaultBranchV3(ctx context.Context, owner string, repo string) (branch string, err error) {
+	repository, _, err := c.V3.Repositories.Get(ctx, owner, repo)
+	if err != nil {
+		return "", err
+	}
+	return repository.GetDefaultBranch(), nil
+}
+
+// SetDefaultBranchV3 sets the default branch of a repository
+func (c *TokenClient) SetDefaultBranchV3(ctx context.Context, owner string, repo string, branch string) (err error) {
+	_, _, err = c.V3.Repositories.Edit(ctx, owner, repo, &github.Repository{
+		DefaultBranch: github.String(branch),
+	})
+	return
+}
+
+// CreateFileV3 commits a single new file to branch via the Contents API, which,
+// unlike the Git Database API, supports empty repositories
+func (c *TokenClient) CreateFileV3(ctx context.Context, owner string, repo string, branch string, path string, content []byte, message string) (sha string, url string, err error) {
+	response, _, err := c.V3.Repositories.CreateFile(ctx, owner, repo, path, &github.RepositoryContentFileOptions{
+		Message: github.String(message),
+		Content: content,
+		Branch:  github.String(branch),
+	})
+	if err != nil {
+		return "", "", err
+	}
+	return response.Commit.GetSHA(), response.Commit.GetHTMLURL(), nil
+}
+
+// GetCommittedDateV4 returns the server-recorded committer date of commit oid
+func (c *TokenClient) GetCommittedDateV4(owner string, repo string, oid string) (date time.Time, err error) {
+	var query CommittedDateV4Query
+	variables := map[string]interface{}{
+		"owner": githubv4.String(owner),
+		"repo":  githubv4.String(repo),
+		"oid":   githubv4.GitObjectID(oid),
+	}
+	err = c.V4.Query(c.Context, &query, variables)
+	if err != nil {
+		return
+	}
+
+	date = query.Repository.Object.Commit.CommittedDate.Time
+	if date.IsZero() {
+		return date, fmt.Errorf("commit %s not found", oid)
+	}
+	return
+}
+
+// GetCommitUrlV4 returns the URL of commit oid
+func (c *TokenClient) GetCommitUrlV4(owner string, repo string, oid string) (url string, err error) {
+	var query CommitUrlV4Query
+	variables := map[string]interface{}{
+		"owner": githubv4.String(owner),
+		"repo":  githubv4.String(repo),
+		"oid":   githubv4.GitObjectID(oid),
+	}
+	err = c.V4.Query(c.Context, &query, variables)
+	if err != nil {
+		return
+	}
+
+	if query.Repository.Object.Commit.Url.URL == nil {
+		return "", fmt.Errorf("commit %s not found", oid)
+	}
+	return query.Repository.Object.Commit.Url.String(), nil
+}
+
+// CreateCommitOnBranchV3 commits changes on top of parent via the Git Database API,
+// allowing explicit author and committer, and fast-forwards branch to the new commit.
+// Additions take their mode from modes, if set, else keep that of the path in parent,
+// defaulting to a regular file (100644) for new paths.
+func (c *TokenClient) CreateCommitOnBranchV3(ctx context.Context, owner string, repo string, branch string, parent string, changes githubv4.FileChanges, modes map[string]string, message string, author *github.CommitAuthor, committer *github.CommitAuthor) (oid string, url string, err error) {
+	parentCommit, _, err := c.V3.Git.GetCommit(ctx, owner, repo, parent)
+	if err != nil {
+		return "", "", err
+	}
+
+	parentTree, _, err := c.V3.Git.GetTree(ctx, owner, repo, parentCommit.GetTree().GetSHA(), true)
+	if err != nil {
+		return "", "", err
+	}
+	if parentTree.GetTruncated() {
+		log.Warnf("tree of %s truncated: existing modes of unlisted paths not preserved", parent)
+	}
+	existingModes := make(map[string]string, len(parentTree.Entries))
+	for _, entry := range parentTree.Entries {
+		if entry.GetType() == "blob" {
+			existingModes[entry.GetPath()] = entry.GetMode()
+		}
+	}
+	modeOf := func(path string) string {
+		if mode, ok := modes[path]; ok {
+			return mode
+		}
+		if mode, ok := existingModes[path]; ok {
+			return mode
+		}
+		return "100644"
+	}
+
+	entries := []*github.TreeEntry{}
+	if changes.Additions != nil {
+		for _, addition := range *changes.Additions {
+			blob, _, err := c.V3.Git.CreateBlob(ctx, owner, repo, &github.Blob{
+				Content:  github.String(string(addition.Contents)),
+				Encoding: github.String("base64"),
+			})
+			if err != nil {
+				return "", "", err
+			}
+			entries = append(entries, &github.TreeEntry{
+				Path: github.String(string(addition.Path)),
+				Mode: github.String(modeOf(string(addition.Path))),
+				Type: github.String("blob"),
+				SHA:  blob.SHA,
+			})
+		}
+	}
+	if changes.Deletions != nil {
+		for _, deletion := range *changes.Deletions {
+			entries = append(entries, &github.TreeEntry{
+				Path: github.String(string(deletion.Path)),
+				Mode: github.String(modeOf(string(deletion.Path))),
+				Type: github.String("blob"),
+			})
+		}
+	}
+
+	tree, _, err := c.V3.Git.CreateTree(ctx, owner, repo, parentCommit.GetTree().GetSHA(), entries)
+	if err != nil {
+		return "", "", err
+	}
+
+	return c.commitTreeOnBranchV3(ctx, owner, repo, branch, parent, tree, message, author, committer)
+}
+
+// CreateTreeCommitOnBranchV3 commits the existing tree identified by treeSHA on top of
+// parent via the Git Database API, and fast-forwards branch to the new commit
+func (c *TokenClient) CreateTreeCommitOnBranchV3(ctx context.Context, owner string, repo string, branch string, parent string, treeSHA string, message string, author *github.CommitAuthor, committer *github.CommitAuthor) (oid string, url string, err error) {
+	tree, _, err := c.V3.Git.GetTree(ctx, owner, repo, treeSHA, false)
+	if err != nil {
+		return "", "", fmt.Errorf("tree %s: %w", treeSHA, err)
+	}
+
+	return c.commitTreeOnBranchV3(ctx, owner, repo, branch, parent, tree, message, author, committer)
+}
+
+func (c *TokenClient) commitTreeOnBranchV3(ctx context.Context, owner string, repo string, branch string, parent string, tree *github.Tree, message string, author *github.CommitAuthor, committer *github.CommitAuthor) (oid string, url string, err error) {
+	commit, _, err := c.V3.Git.CreateCommit(ctx, owner, repo, &github.Commit{
+		Message:   github.String(message),
+		Tree:      tree,
+		Parents:   []*github.Commit{{SHA: github.String(parent)}},
+		Author:    author,
+		Committer: committer,
+	}, nil)
+	if err != nil {
+		return "", "", err
+	}
+
+	_, _, err = c.V3.Git.UpdateRef(ctx, owner, repo, &github.Reference{
+		Ref:    github.String(fmt.Sprintf("heads/%s", branch)),
+		Object: &github.GitObject{SHA: commit.SHA},
+	}, false)
+	if err != nil {
+		return "", "", err
+	}
+
+	return commit.GetSHA(), commit.GetHTMLURL(), nil
+}
+
+// CreateCommitCommentV3 posts body as a comment on commit sha
+func (c *TokenClient) CreateCommitCommentV3(ctx context.Context, owner string, repo string, sha string, body string) (url string, err error) {
+	comment, _, err := c.V3.Repositories.CreateComment(ctx, owner, repo, sha, &github.RepositoryComment{
+		Body: github.String(body),
+	})
+	if err != nil {
+		return "", err
+	}
+	return comment.GetHTMLURL(), nil
+}
+
+// SyncBranchV3 brings branch up to date with base, fast-forwarding it if possible and merging
+// base into it otherwise, returning the resulting branch tip
+func (c *TokenClient) SyncBranchV3(ctx context.Context, owner string, repo string, branch string, base string) (oid string, err error) {
+	comparison, _, err := c.V3.Repositories.CompareCommits(ctx, owner, repo, branch, base, &github.ListOptions{PerPage: 1})
+	if err != nil {
+		return "", err
+	}
+	branchSHA := comparison.GetBaseCommit().GetSHA()
+
+	switch comparison.GetStatus() {
+	case "identical", "behind":
+		log.Infof("branch %q already contains %q", branch, base)
+		return branchSHA, nil
+	case "ahead":
+		baseSHA, _, err := c.GetCommitSHA(ctx, owner, repo, base)
+		if err != nil {
+			return "", err
+		}
+		log.Infof("fast-forwarding branch %q to %q (%s)", branch, base, *baseSHA)
+		ref := &github.Reference{
+			Ref:    github.String("refs/heads/" + branch),
+			Object: &github.GitObject{SHA: baseSHA},
+		}
+		if _, _, err := c.V3.Git.UpdateRef(ctx, owner, repo, ref, false); err != nil {
+			return "", err
+		}
+		return *baseSHA, nil
+	}
+
+	log.Infof("merging %q into branch %q", base, branch)
+	commit, resp, err := c.V3.Repositories.Merge(ctx, owner, repo, &github.RepositoryMergeRequest{
+		Base: github.String(branch),
+		Head: github.String(base),
+	})
+	switch {
+	case resp != nil && resp.StatusCode == http.StatusConflict:
+		return "", ErrMergeConflict
+	case err != nil:
+		return "", err
+	case resp.StatusCode == http.StatusNoContent:
+		return branchSHA, nil
+	}
+	return commit.GetSHA(), nil
+}
+
+// ResetBranchV3 force-updates branch to commit sha, discarding any commits not reachable from it
+func (c *TokenClient) ResetBranchV3(ctx context.Context, owner string, repo string, branch string, sha string) (err error) {
+	_, _, err = c.V3.Git.UpdateRef(ctx, owner, repo, &github.Reference{
+		Ref:    github.String(fmt.Sprintf("heads/%s", branch)),
+		Object: &github.GitObject{SHA: github.String(sha)},
+	}, true)
+	return
+}
+
+// CountOpenPullRequestsV4 returns the number of open pull requests in the repository
+// authored by the token owner, be it a user or an app
+func (c *TokenClient) CountOpenPullRequestsV4(owner string, repo string) (count int, err error) {
+	login, err := c.GetViewerLoginV4()
+	if err != nil {
+		return 0, err
+	}
+	author := login
+	if app, found := strings.CutSuffix(login, "[bot]"); found {
+		author = "app/" + app
+	}
+
+	var query SearchCountV4Query
+	variables := map[string]interface{}{
+		"query": githubv4.String(fmt.Sprintf("repo:%s/%s is:pr is:open author:%s", owner, repo, author)),
+	}
+	err = c.V4.Query(c.Context, &query, variables)
+	return int(query.Search.IssueCount), err
+}
+
+// GetOpenPullRequestV4 returns the URL of an open pull request from head to base, if any
+func (c *TokenClient) GetOpenPullRequestV4(owner string, repo string, head string, base string) (url string, err error) {
+	var query OpenPullRequestV4Query
+	variables := map[string]interface{}{
+		"owner": githubv4.String(owner),
+		"repo":  githubv4.String(repo),
+		"head":  githubv4.String(head),
+		"base":  githubv4.String(base),
+	}
+	err = c.V4.Query(c.Context, &query, variables)
+	if err != nil || len(query.Repository.PullRequests.Nodes) == 0 {
+		return "", err
+	}
+	return query.Repository.PullRequests.Nodes[0].Permalink.String(), nil
+}
+
 func (c *TokenClient) CreatePullRequestV4(input githubv4.CreatePullRequestInput) (url string, err error) {
 	var mutation CreatePullRequestV4Mutation
 
@@ -0,0 +1,75 @@
+package remote
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// idempotencyKeyer sets an Idempotency-Key header on mutating requests, once a key is set.
+// Each mutating request gets its own ordinal suffix, so that a gateway de-duplicating
+// by key only drops replays of the same request; retries below it reuse the header.
+type idempotencyKeyer struct {
+	transport http.RoundTripper
+
+	mu    sync.Mutex
+	key   string
+	count int
+}
+
+func (k *idempotencyKeyer) setKey(key string) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	k.key = key
+	k.count = 0
+}
+
+// nextKey returns the key for the next mutating request, or "" if no key is set
+func (k *idempotencyKeyer) nextKey() string {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.key == "" {
+		return ""
+	}
+	k.count++
+	return fmt.Sprintf("%s-%d", k.key, k.count)
+}
+
+func (k *idempotencyKeyer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if isMutation(req) {
+		if key := k.nextKey(); key != "" {
+			req = req.Clone(req.Context())
+			req.Header.Set("Idempotency-Key", key)
+		}
+	}
+	return k.transport.RoundTrip(req)
+}
+
+// isMutation reports whether req modifies state: any REST request other than GET,
+// HEAD or OPTIONS, or a GraphQL mutation
+func isMutation(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	}
+	if !strings.HasSuffix(req.URL.Path, "/graphql") {
+		return true
+	}
+	if req.GetBody == nil {
+		return false
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return false
+	}
+	defer body.Close()
+	var payload struct {
+		Query string `json:"query"`
+	}
+	if err := json.NewDecoder(body).Decode(&payload); err != nil {
+		return false
+	}
+	return strings.HasPrefix(strings.TrimSpace(payload.Query), "mutation")
+}
@@ -0,0 +1,59 @@
+package remote
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestIdempotencyKeyer(t *testing.T) {
+	var mu sync.Mutex
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, r.Header.Get("Idempotency-Key"))
+	}))
+	defer server.Close()
+
+	keyer := &idempotencyKeyer{}
+	client := &http.Client{
+		Transport: newTransport(http.DefaultTransport, clientOptions{idempotencyKeyer: keyer}),
+	}
+
+	requests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{http.MethodPost, "/repos/o/r/git/refs", `{}`},
+		{http.MethodGet, "/repos/o/r", ""},
+		{http.MethodPost, "/graphql", `{"query":"query{viewer{login}}"}`},
+		{http.MethodPost, "/graphql", `{"query":"mutation($input:CreateRefInput!){createRef(input:$input){clientMutationId}}"}`},
+		{http.MethodPatch, "/repos/o/r", `{}`},
+	}
+	do := func() {
+		for _, r := range requests {
+			req, err := http.NewRequest(r.method, server.URL+r.path, strings.NewReader(r.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+		}
+	}
+
+	do()
+	keyer.setKey("abc")
+	do()
+
+	want := []string{"", "", "", "", "", "abc-1", "", "", "abc-2", "abc-3"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("Idempotency-Key headers: got %q; expected %q", got, want)
+	}
+}
@@ -0,0 +1,122 @@
+package remote
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/go-github/v64/github"
+)
+
+// SetBranchLock sets the lock_branch status of a branch, preserving any other
+// branch protection already in place
+func (c *TokenClient) SetBranchLock(ctx context.Context, owner string, repo string, branch string, locked bool) (err error) {
+	request := &github.ProtectionRequest{}
+
+	protection, _, err := c.V3.Repositories.GetBranchProtection(ctx, owner, repo, branch)
+	switch {
+	case errors.Is(err, github.ErrBranchNotProtected):
+		if !locked {
+			// nothing to unlock
+			return nil
+		}
+	case err != nil:
+		return err
+	default:
+		request = protectionRequest(protection)
+	}
+
+	request.LockBranch = github.Bool(locked)
+	_, _, err = c.V3.Repositories.UpdateBranchProtection(ctx, owner, repo, branch, request)
+	return
+}
+
+// protectionRequest converts existing branch protection into an equivalent update request
+func protectionRequest(p *github.Protection) *github.ProtectionRequest {
+	request := &github.ProtectionRequest{
+		BlockCreations:   github.Bool(p.GetBlockCreations().GetEnabled()),
+		AllowForkSyncing: github.Bool(p.GetAllowForkSyncing().GetEnabled()),
+	}
+	if p.EnforceAdmins != nil {
+		request.EnforceAdmins = p.EnforceAdmins.Enabled
+	}
+	if p.RequireLinearHistory != nil {
+		request.RequireLinearHistory = github.Bool(p.RequireLinearHistory.Enabled)
+	}
+	if p.AllowForcePushes != nil {
+		request.AllowForcePushes = github.Bool(p.AllowForcePushes.Enabled)
+	}
+	if p.AllowDeletions != nil {
+		request.AllowDeletions = github.Bool(p.AllowDeletions.Enabled)
+	}
+	if p.RequiredConversationResolution != nil {
+		request.RequiredConversationResolution = github.Bool(p.RequiredConversationResolution.Enabled)
+	}
+
+	if checks := p.RequiredStatusChecks; checks != nil {
+		request.RequiredStatusChecks = &github.RequiredStatusChecks{
+			Strict: checks.Strict,
+			Checks: checks.Checks,
+		}
+		if checks.Checks == nil {
+			request.RequiredStatusChecks.Contexts = checks.Contexts
+		}
+	}
+
+	if reviews := p.RequiredPullRequestReviews; reviews != nil {
+		request.RequiredPullRequestReviews = &github.PullRequestReviewsEnforcementRequest{
+			DismissStaleReviews:          reviews.DismissStaleReviews,
+			RequireCodeOwnerReviews:      reviews.RequireCodeOwnerReviews,
+			RequiredApprovingReviewCount: reviews.RequiredApprovingReviewCount,
+			RequireLastPushApproval:      github.Bool(reviews.RequireLastPushApproval),
+		}
+		if bypass := reviews.BypassPullRequestAllowances; bypass != nil {
+			request.RequiredPullRequestReviews.BypassPullRequestAllowancesRequest = &github.BypassPullRequestAllowancesRequest{
+				Users: userLogins(bypass.Users),
+				Teams: teamSlugs(bypass.Teams),
+				Apps:  appSlugs(bypass.Apps),
+			}
+		}
+		if dismissal := reviews.DismissalRestrictions; dismissal != nil {
+			users, teams, apps := userLogins(dismissal.Users), teamSlugs(dismissal.Teams), appSlugs(dismissal.Apps)
+			request.RequiredPullRequestReviews.DismissalRestrictionsRequest = &github.DismissalRestrictionsRequest{
+				Users: &users,
+				Teams: &teams,
+				Apps:  &apps,
+			}
+		}
+	}
+
+	if restrictions := p.Restrictions; restrictions != nil {
+		request.Restrictions = &github.BranchRestrictionsRequest{
+			Users: userLogins(restrictions.Users),
+			Teams: teamSlugs(restrictions.Teams),
+			Apps:  appSlugs(restrictions.Apps),
+		}
+	}
+
+	return request
+}
+
+func userLogins(users []*github.User) []string {
+	logins := make([]string, 0, len(users))
+	for _, user := range users {
+		logins = append(logins, user.GetLogin())
+	}
+	return logins
+}
+
+func teamSlugs(teams []*github.Team) []string {
+	slugs := make([]string, 0, len(teams))
+	for _, team := range teams {
+		slugs = append(slugs, team.GetSlug())
+	}
+	return slugs
+}
+
+func appSlugs(apps []*github.App) []string {
+	slugs := make([]string, 0, len(apps))
+	for _, app := range apps {
+		slugs = append(slugs, app.GetSlug())
+	}
+	return slugs
+}
@@ -0,0 +1,78 @@
+package remote
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/google/go-github/v64/github"
+)
+
+func TestProtectionRequest(t *testing.T) {
+	tests := []struct {
+		name       string
+		protection *github.Protection
+		expected   *github.ProtectionRequest
+	}{
+		{
+			name:       "Empty protection",
+			protection: &github.Protection{},
+			expected: &github.ProtectionRequest{
+				BlockCreations:   github.Bool(false),
+				AllowForkSyncing: github.Bool(false),
+			},
+		},
+		{
+			name: "Existing protection",
+			protection: &github.Protection{
+				EnforceAdmins:        &github.AdminEnforcement{Enabled: true},
+				RequireLinearHistory: &github.RequireLinearHistory{Enabled: true},
+				RequiredStatusChecks: &github.RequiredStatusChecks{
+					Strict:   true,
+					Contexts: &[]string{"ci"},
+				},
+				RequiredPullRequestReviews: &github.PullRequestReviewsEnforcement{
+					RequiredApprovingReviewCount: 2,
+					BypassPullRequestAllowances: &github.BypassPullRequestAllowances{
+						Users: []*github.User{{Login: github.String("octocat")}},
+					},
+				},
+				Restrictions: &github.BranchRestrictions{
+					Teams: []*github.Team{{Slug: github.String("admins")}},
+				},
+			},
+			expected: &github.ProtectionRequest{
+				EnforceAdmins:        true,
+				RequireLinearHistory: github.Bool(true),
+				BlockCreations:       github.Bool(false),
+				AllowForkSyncing:     github.Bool(false),
+				RequiredStatusChecks: &github.RequiredStatusChecks{
+					Strict:   true,
+					Contexts: &[]string{"ci"},
+				},
+				RequiredPullRequestReviews: &github.PullRequestReviewsEnforcementRequest{
+					RequiredApprovingReviewCount: 2,
+					RequireLastPushApproval:      github.Bool(false),
+					BypassPullRequestAllowancesRequest: &github.BypassPullRequestAllowancesRequest{
+						Users: []string{"octocat"},
+						Teams: []string{},
+						Apps:  []string{},
+					},
+				},
+				Restrictions: &github.BranchRestrictionsRequest{
+					Users: []string{},
+					Teams: []string{"admins"},
+					Apps:  []string{},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := protectionRequest(tt.protection)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("protectionRequest() = %+v; expected %+v", result, tt.expected)
+			}
+		})
+	}
+}
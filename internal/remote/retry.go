@@ -0,0 +1,140 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/apex/log"
+)
+
+const maxRetryDelay = 60 * time.Second
+
+// WithRetries retries each failed request up to maxRetries times, and at most
+// budget times in total across all requests of the client (unlimited if negative)
+func WithRetries(maxRetries int, budget int) ClientOption {
+	return func(o *clientOptions) {
+		o.maxRetries = maxRetries
+		o.retryBudget = budget
+	}
+}
+
+// retrier retries requests failing transiently, with exponential backoff unless
+// the server specifies when to retry
+type retrier struct {
+	transport  http.RoundTripper
+	maxRetries int
+	budget     atomic.Int64
+	baseDelay  time.Duration
+	// retries, if set, counts the retries made
+	retries *atomic.Int64
+}
+
+func newRetrier(transport http.RoundTripper, maxRetries int, budget int) *retrier {
+	r := &retrier{
+		transport:  transport,
+		maxRetries: maxRetries,
+		baseDelay:  time.Second,
+	}
+	r.budget.Store(int64(budget))
+	return r
+}
+
+func (r *retrier) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		if attempt > 0 && req.Body != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq = req.Clone(req.Context())
+			attemptReq.Body = body
+		}
+
+		resp, err := r.transport.RoundTrip(attemptReq)
+		delay, retryable := r.retryDelay(req, attempt, resp, err)
+		if !retryable || attempt >= r.maxRetries || (req.Body != nil && req.GetBody == nil) {
+			return resp, err
+		}
+		if !r.takeRetry() {
+			log.Warn("retry budget exhausted")
+			if err != nil {
+				return nil, fmt.Errorf("retry budget exhausted: %w", err)
+			}
+			return resp, nil
+		}
+		if r.retries != nil {
+			r.retries.Add(1)
+		}
+
+		if err != nil {
+			log.Warnf("%s %s failed (%s): retrying in %s", req.Method, req.URL, err, delay)
+		} else {
+			log.Warnf("%s %s failed (%s): retrying in %s", req.Method, req.URL, resp.Status, delay)
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// takeRetry consumes one retry from the budget, returning false if none remain
+func (r *retrier) takeRetry() bool {
+	for {
+		budget := r.budget.Load()
+		switch {
+		case budget < 0:
+			return true
+		case budget == 0:
+			return false
+		case r.budget.CompareAndSwap(budget, budget-1):
+			return true
+		}
+	}
+}
+
+// retryDelay reports whether the outcome of an attempt is transient, and how long to wait before retrying.
+// Mutations are only retried when rejected by rate limiting: after a network error or server error,
+// they may have been applied regardless, and replaying them could duplicate their effect.
+func (r *retrier) retryDelay(req *http.Request, attempt int, resp *http.Response, err error) (delay time.Duration, retryable bool) {
+	// double the delay per attempt up to its maximum, without overflowing
+	delay = r.baseDelay
+	for i := 0; i < attempt && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxRetryDelay)
+
+	var ssoErr *SSOError
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded), errors.As(err, &ssoErr):
+		return 0, false
+	case err != nil:
+		return delay, !isMutation(req)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+	case resp.StatusCode == http.StatusForbidden && (resp.Header.Get("Retry-After") != "" || resp.Header.Get("X-RateLimit-Remaining") == "0"):
+	case resp.StatusCode >= 500 && resp.StatusCode != http.StatusNotImplemented && !isMutation(req):
+	default:
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		delay = min(time.Duration(seconds)*time.Second, maxRetryDelay)
+	} else if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil && resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		delay = min(max(time.Until(time.Unix(reset, 0)), 0), maxRetryDelay)
+	}
+	return delay, true
+}
@@ -0,0 +1,154 @@
+package remote
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetrier(t *testing.T) {
+	tests := []struct {
+		name       string
+		failures   int32
+		status     int
+		maxRetries int
+		budget     int
+		requests   int
+		mutation   bool
+		wantCalls  int32
+		wantStatus int
+	}{
+		{
+			name:       "Recovers within retries",
+			failures:   2,
+			status:     http.StatusBadGateway,
+			maxRetries: 3,
+			budget:     -1,
+			requests:   1,
+			wantCalls:  3,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Retries exhausted",
+			failures:   5,
+			status:     http.StatusServiceUnavailable,
+			maxRetries: 1,
+			budget:     -1,
+			requests:   1,
+			wantCalls:  2,
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "Budget shared across requests",
+			failures:   100,
+			status:     http.StatusTooManyRequests,
+			maxRetries: 5,
+			budget:     2,
+			requests:   2,
+			wantCalls:  4,
+			wantStatus: http.StatusTooManyRequests,
+		},
+		{
+			name:       "Mutation not retried on server error",
+			failures:   1,
+			status:     http.StatusBadGateway,
+			maxRetries: 3,
+			budget:     -1,
+			requests:   1,
+			mutation:   true,
+			wantCalls:  1,
+			wantStatus: http.StatusBadGateway,
+		},
+		{
+			name:       "Mutation retried when rate limited",
+			failures:   1,
+			status:     http.StatusTooManyRequests,
+			maxRetries: 3,
+			budget:     -1,
+			requests:   1,
+			mutation:   true,
+			wantCalls:  2,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Not retryable",
+			failures:   1,
+			status:     http.StatusNotFound,
+			maxRetries: 3,
+			budget:     -1,
+			requests:   1,
+			wantCalls:  1,
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			payload := `{"query":"query{viewer{login}}"}`
+			if tt.mutation {
+				payload = `{"query":"mutation($input:CreateRefInput!){createRef(input:$input){clientMutationId}}"}`
+			}
+			var calls atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+				if string(body) != payload {
+					t.Errorf("request body = %q, want %q", body, payload)
+				}
+				if calls.Add(1) <= tt.failures {
+					w.WriteHeader(tt.status)
+				}
+			}))
+			defer server.Close()
+
+			r := newRetrier(http.DefaultTransport, tt.maxRetries, tt.budget)
+			r.baseDelay = time.Millisecond
+			r.retries = &atomic.Int64{}
+			client := &http.Client{Transport: r}
+
+			var status int
+			for range tt.requests {
+				resp, err := client.Post(server.URL+"/graphql", "application/json", strings.NewReader(payload))
+				if err != nil {
+					t.Fatal(err)
+				}
+				resp.Body.Close()
+				status = resp.StatusCode
+			}
+
+			if got := calls.Load(); got != tt.wantCalls {
+				t.Errorf("calls = %d, want %d", got, tt.wantCalls)
+			}
+			if got := r.retries.Load(); got != int64(tt.wantCalls)-int64(tt.requests) {
+				t.Errorf("retries = %d, want %d", got, int64(tt.wantCalls)-int64(tt.requests))
+			}
+			if status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	r := newRetrier(http.DefaultTransport, 100, -1)
+	req := httptest.NewRequest(http.MethodGet, "/repos/o/r", nil)
+	resp := &http.Response{StatusCode: http.StatusBadGateway, Header: http.Header{}}
+
+	for _, tt := range []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{attempt: 0, want: time.Second},
+		{attempt: 3, want: 8 * time.Second},
+		{attempt: 6, want: maxRetryDelay},
+		{attempt: 99, want: maxRetryDelay},
+	} {
+		delay, retryable := r.retryDelay(req, tt.attempt, resp, nil)
+		if !retryable || delay != tt.want {
+			t.Errorf("retryDelay(%d) = %s, %t; want %s, true", tt.attempt, delay, retryable, tt.want)
+		}
+	}
+}
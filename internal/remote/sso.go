@@ -0,0 +1,93 @@
+package remote
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// SSOError is returned when the token has not been authorized for SAML single sign-on
+// with the organization owning the requested resource
+type SSOError struct {
+	URL string
+}
+
+func (e *SSOError) Error() string {
+	if e.URL == "" {
+		return "token not authorized for organization SAML SSO"
+	}
+	return fmt.Sprintf("token not authorized for organization SAML SSO: authorize it at %s", e.URL)
+}
+
+// ssoDetector maps responses requiring SAML SSO authorization to an SSOError: REST responses
+// flagged by the X-GitHub-SSO header, and GraphQL responses (HTTP 200) with a FORBIDDEN error
+// for SAML enforcement
+type ssoDetector struct {
+	transport http.RoundTripper
+}
+
+func (d *ssoDetector) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := d.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if ssoErr := parseSSOHeader(resp.Header.Get("X-GitHub-SSO")); ssoErr != nil {
+		resp.Body.Close()
+		return nil, ssoErr
+	}
+	if !strings.HasSuffix(req.URL.Path, "/graphql") || resp.StatusCode != http.StatusOK {
+		return resp, nil
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if ssoErr := parseSSOGraphQLErrors(body); ssoErr != nil {
+		return nil, ssoErr
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// parseSSOGraphQLErrors returns an SSOError if a GraphQL response body reports a resource
+// protected by organization SAML enforcement
+func parseSSOGraphQLErrors(body []byte) *SSOError {
+	if !bytes.Contains(body, []byte("SAML enforcement")) {
+		return nil
+	}
+	var response struct {
+		Errors []struct {
+			Type    string `json:"type"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil
+	}
+	for _, e := range response.Errors {
+		if e.Type == "FORBIDDEN" && strings.Contains(e.Message, "SAML enforcement") {
+			return &SSOError{}
+		}
+	}
+	return nil
+}
+
+// parseSSOHeader returns an SSOError if header (e.g. "required; url=https://...") requires authorization
+func parseSSOHeader(header string) *SSOError {
+	directive, params, _ := strings.Cut(header, ";")
+	if strings.TrimSpace(directive) != "required" {
+		return nil
+	}
+	ssoErr := &SSOError{}
+	for _, param := range strings.Split(params, ";") {
+		if url, found := strings.CutPrefix(strings.TrimSpace(param), "url="); found {
+			ssoErr.URL = url
+		}
+	}
+	return ssoErr
+}
@@ -0,0 +1,109 @@
+package remote
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestParseSSOHeader(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		want    bool
+		wantURL string
+	}{
+		{
+			name: "No header",
+		},
+		{
+			name:   "Partial results",
+			header: "partial-results; organizations=21955855,20582480",
+		},
+		{
+			name:    "Required",
+			header:  "required; url=https://github.com/orgs/example/sso?authorization_request=abc",
+			want:    true,
+			wantURL: "https://github.com/orgs/example/sso?authorization_request=abc",
+		},
+		{
+			name:   "Required without url",
+			header: "required",
+			want:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseSSOHeader(tt.header)
+			switch {
+			case !tt.want && got != nil:
+				t.Errorf("parseSSOHeader() = %v, want nil", got)
+			case tt.want && got == nil:
+				t.Errorf("parseSSOHeader() = nil, want URL %q", tt.wantURL)
+			case tt.want && got.URL != tt.wantURL:
+				t.Errorf("parseSSOHeader() URL = %q, want %q", got.URL, tt.wantURL)
+			}
+		})
+	}
+}
+
+func TestSSODetector(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-GitHub-SSO", "required; url=https://github.com/orgs/example/sso")
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: newTransport(http.DefaultTransport, clientOptions{})}
+	_, err := client.Get(server.URL)
+
+	var ssoErr *SSOError
+	if !errors.As(err, &ssoErr) {
+		t.Fatalf("Get() error = %v, want SSOError", err)
+	}
+	if ssoErr.URL != "https://github.com/orgs/example/sso" {
+		t.Errorf("SSOError.URL = %q", ssoErr.URL)
+	}
+}
+
+func TestSSODetectorGraphQL(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.URL.Query().Get("protected") == "" {
+			w.Write([]byte(`{"data":{"viewer":{"login":"octocat"}}}`))
+			return
+		}
+		w.Header().Set("X-GitHub-SSO", "partial-results; organizations=21955855")
+		w.Write([]byte(`{"data":{"repository":null},"errors":[{"type":"FORBIDDEN","path":["repository"],` +
+			`"message":"Resource protected by organization SAML enforcement. You must grant your Personal Access token access to this organization."}]}`))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: newTransport(http.DefaultTransport, clientOptions{maxRetries: 3, retryBudget: -1})}
+
+	resp, err := client.Post(server.URL+"/graphql", "application/json", strings.NewReader(`{"query":"query{viewer{login}}"}`))
+	if err != nil {
+		t.Fatalf("Post() unexpected error: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != `{"data":{"viewer":{"login":"octocat"}}}` {
+		t.Errorf("Post() body = %s", body)
+	}
+
+	calls.Store(0)
+	_, err = client.Post(server.URL+"/graphql?protected=1", "application/json", strings.NewReader(`{"query":"query{repository{id}}"}`))
+	var ssoErr *SSOError
+	if !errors.As(err, &ssoErr) {
+		t.Fatalf("Post() error = %v, want SSOError", err)
+	}
+	if got := calls.Load(); got != 1 {
+		t.Errorf("calls = %d, want 1 (SSO errors are not retried)", got)
+	}
+}
@@ -0,0 +1,123 @@
+package remote
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// ClientOption configures the HTTP transport of a TokenClient
+type ClientOption func(*clientOptions)
+
+type clientOptions struct {
+	maxConcurrencyPerHost int
+	maxRetries            int
+	retryBudget           int
+	idempotencyKeyer      *idempotencyKeyer
+	apiVersion            string
+	retries               *atomic.Int64
+}
+
+// WithMaxConcurrencyPerHost caps in-flight requests to each host across all clients in the process
+func WithMaxConcurrencyPerHost(n int) ClientOption {
+	return func(o *clientOptions) {
+		o.maxConcurrencyPerHost = n
+	}
+}
+
+// WithAPIVersion pins the REST API version requested by the V3 client (default that of go-github)
+func WithAPIVersion(version string) ClientOption {
+	return func(o *clientOptions) {
+		o.apiVersion = version
+	}
+}
+
+// newTransport builds the HTTP transport stack described by options
+func newTransport(base http.RoundTripper, options clientOptions) http.RoundTripper {
+	transport := base
+	if options.maxConcurrencyPerHost > 0 {
+		transport = &hostGovernor{
+			transport: transport,
+			limit:     options.maxConcurrencyPerHost,
+		}
+	}
+	// beneath the retrier, so that SSO failures are not retried
+	transport = &ssoDetector{transport: transport}
+	if options.maxRetries > 0 {
+		r := newRetrier(transport, options.maxRetries, options.retryBudget)
+		r.retries = options.retries
+		transport = r
+	}
+	if options.idempotencyKeyer != nil {
+		options.idempotencyKeyer.transport = transport
+		transport = options.idempotencyKeyer
+	}
+	return transport
+}
+
+var (
+	hostSlotsMu sync.Mutex
+	hostSlots   = map[string]chan struct{}{}
+)
+
+// hostSlotsFor returns the process-wide semaphore for host, created with the given limit on first use
+func hostSlotsFor(host string, limit int) chan struct{} {
+	hostSlotsMu.Lock()
+	defer hostSlotsMu.Unlock()
+	slots, ok := hostSlots[host]
+	if !ok {
+		slots = make(chan struct{}, limit)
+		hostSlots[host] = slots
+	}
+	return slots
+}
+
+// hostGovernor limits concurrent requests per host, holding each slot until the response body is closed
+type hostGovernor struct {
+	transport http.RoundTripper
+	limit     int
+}
+
+func (g *hostGovernor) RoundTrip(req *http.Request) (*http.Response, error) {
+	slots := hostSlotsFor(req.URL.Host, g.limit)
+
+	select {
+	case slots <- struct{}{}:
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	}
+	release := sync.OnceFunc(func() { <-slots })
+
+	resp, err := g.transport.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	defer b.release()
+	return b.ReadCloser.Close()
+}
+
+// apiVersioner sets the X-GitHub-Api-Version header of REST requests, if a version is set
+type apiVersioner struct {
+	transport http.RoundTripper
+	version   string
+}
+
+func (v *apiVersioner) RoundTrip(req *http.Request) (*http.Response, error) {
+	if v.version != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("X-GitHub-Api-Version", v.version)
+	}
+	return v.transport.RoundTrip(req)
+}
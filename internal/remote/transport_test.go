@@ -0,0 +1,82 @@
+package remote
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHostGovernor(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	limit := 2
+	client := &http.Client{
+		Transport: newTransport(http.DefaultTransport, clientOptions{maxConcurrencyPerHost: limit}),
+	}
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Get(server.URL)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+
+	if got := maxInFlight.Load(); got > int32(limit) {
+		t.Errorf("hostGovernor allowed %d concurrent requests; expected at most %d", got, limit)
+	}
+}
+
+func TestAPIVersioner(t *testing.T) {
+	var got string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("X-GitHub-Api-Version")
+	}))
+	defer server.Close()
+
+	for _, version := range []string{"2022-11-28", ""} {
+		client := &http.Client{Transport: &apiVersioner{transport: http.DefaultTransport, version: version}}
+		req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("X-GitHub-Api-Version", "default")
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		want := version
+		if want == "" {
+			want = "default"
+		}
+		if got != want {
+			t.Errorf("apiVersioner(%q) sent version %q; expected %q", version, got, want)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package util
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestJSONSchema(t *testing.T) {
+	type item struct {
+		Path string `json:"path"`
+		Hash string `json:"hash,omitempty"`
+	}
+	type document struct {
+		Name     string            `json:"name"`
+		Count    int               `json:"count,omitempty"`
+		Items    []item            `json:"items"`
+		Labels   map[string]string `json:"labels,omitempty"`
+		At       *time.Time        `json:"at,omitempty"`
+		Ignored  string            `json:"-"`
+		internal string
+	}
+
+	got, err := json.Marshal(JSONSchema(document{}, "document"))
+	if err != nil {
+		t.Fatalf("json.Marshal() unexpected error: %v", err)
+	}
+	want := `{"$schema":"https://json-schema.org/draft/2020-12/schema","additionalProperties":false,` +
+		`"properties":{"at":{"format":"date-time","type":"string"},"count":{"type":"integer"},` +
+		`"items":{"items":{"additionalProperties":false,"properties":{"hash":{"type":"string"},"path":{"type":"string"}},"required":["path"],"type":"object"},"type":"array"},` +
+		`"labels":{"additionalProperties":{"type":"string"},"type":"object"},"name":{"type":"string"}},` +
+		`"required":["name","items"],"title":"document","type":"object"}`
+	if string(got) != want {
+		t.Errorf("JSONSchema() = %s; expected %s", got, want)
+	}
+}
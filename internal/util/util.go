@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"os"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/apex/log"
 	"github.com/spf13/viper"
@@ -40,6 +43,35 @@ func GithubActionsContext() *RepositoryContext {
 	return nil
 }
 
+// ChangeTarget returns the target branch of the change (e.g. pull request) being built in CI, or an empty string
+func ChangeTarget() string {
+	return cmp.Or[string](
+		os.Getenv("GITHUB_BASE_REF"), // GitHub Actions
+		os.Getenv("CHANGE_TARGET"),   // Jenkins
+	)
+}
+
+// ResolveBaseBranch determines the base of a new branch per preference, trying in turn the
+// flag value and then, for "flag,default", the default branch or, for "flag,target", the CI change target
+func ResolveBaseBranch(preference string, flagValue string, defaultBranch string) (string, error) {
+	if flagValue != "" {
+		return flagValue, nil
+	}
+	switch preference {
+	case "flag,default":
+		return defaultBranch, nil
+	case "flag-only":
+		return "", fmt.Errorf("no base branch specified")
+	case "flag,target":
+		if target := ChangeTarget(); target != "" {
+			return target, nil
+		}
+		return "", fmt.Errorf("no base branch specified or change target found")
+	default:
+		return "", fmt.Errorf("invalid base preference %q", preference)
+	}
+}
+
 // IsCommitHash returns true if the ref looks like a commit hash
 func IsCommitHash(ref string) bool {
 	commitHashPattern := `^[0-9a-f]{7,40}$`
@@ -118,19 +150,52 @@ func NormalizeRefName(refName string, defaultRefType string) (string, error) {
 
 // BuildCommitMessage generates a commit message from the message and trailers configuration
 func BuildCommitMessage() (message string) {
+	return ComposeCommitMessage(viper.GetString("message"))
+}
+
+// ComposeCommitMessage generates a commit message from the given message and the trailers configuration
+func ComposeCommitMessage(message string) string {
 	messageParts := []string{}
-	if message := viper.GetString("message"); message != "" {
+	if message != "" {
+		message = PrefixSubject(message, viper.GetString("subject-prefix"))
 		if strings.Index(message, "\n") > 72 {
 			log.Warn("commit message title exceeds 72 characters and will be wrapped by GitHub")
 		}
-		messageParts = append(messageParts, message)
+		messageParts = append(messageParts, strings.TrimRight(message, "\n"))
 	}
 	if trailers := BuildTrailers(); len(trailers) > 0 {
+		// git only recognizes trailers in the last paragraph of the message
 		messageParts = append(messageParts, "")
+		if separator := viper.GetString("message-trailer-separator"); separator != "" {
+			messageParts = append(messageParts, separator, "")
+		}
 		messageParts = append(messageParts, trailers...)
 	}
-	message = strings.Join(messageParts, "\n")
-	return
+	return strings.Join(messageParts, "\n")
+}
+
+var trailerPattern = regexp.MustCompile(`^[A-Za-z0-9-]+:\s`)
+
+// ValidateTrailerSeparator checks that separator can stand as its own paragraph between
+// message body and trailers without preventing git from recognizing the trailers
+func ValidateTrailerSeparator(separator string) error {
+	for _, line := range strings.Split(separator, "\n") {
+		switch {
+		case strings.TrimSpace(line) == "":
+			return fmt.Errorf("trailer separator cannot contain blank lines")
+		case trailerPattern.MatchString(line):
+			return fmt.Errorf("trailer separator cannot contain trailer-like line %q", line)
+		}
+	}
+	return nil
+}
+
+// PrefixSubject prepends prefix to the subject line of message, unless already present
+func PrefixSubject(message string, prefix string) string {
+	if prefix == "" || strings.HasPrefix(message, strings.TrimRight(prefix, " ")) {
+		return message
+	}
+	return prefix + message
 }
 
 // BuildTrailers generates the complete list of trailers from the configuration
@@ -147,8 +212,49 @@ func BuildTrailers() (trailers []string) {
 			trailers = append(trailers, fmt.Sprintf("%s: %s", trailerKey, strings.Join(userParts, " ")))
 		}
 	}
-	for key, value := range viper.GetStringMapString("trailer") {
-		trailers = append(trailers, fmt.Sprintf("%s: %s", key, value))
+	extraTrailers := viper.GetStringMapString("trailer")
+	for _, key := range sortedKeys(extraTrailers) {
+		trailers = append(trailers, fmt.Sprintf("%s: %s", key, extraTrailers[key]))
+	}
+	envTrailers := viper.GetStringMapString("trailer-from-env")
+	for _, key := range sortedKeys(envTrailers) {
+		if value := os.Getenv(envTrailers[key]); value != "" {
+			trailers = append(trailers, fmt.Sprintf("%s: %s", key, value))
+		}
 	}
 	return
 }
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+var timezoneOffsetRegex = regexp.MustCompile(`^(?:UTC)?([+-])(\d{2}):?(\d{2})$`)
+
+// ParseTimezone resolves an IANA time zone name (e.g. "Europe/Zurich", "UTC" or "Local")
+// or a fixed offset (e.g. "+02:00", "-0530" or "UTC+01:00") to a location
+func ParseTimezone(timezone string) (*time.Location, error) {
+	if matches := timezoneOffsetRegex.FindStringSubmatch(timezone); matches != nil {
+		hours, _ := strconv.Atoi(matches[2])
+		minutes, _ := strconv.Atoi(matches[3])
+		if hours > 14 || minutes > 59 {
+			return nil, fmt.Errorf("invalid timezone offset %q", timezone)
+		}
+		offset := (hours*60 + minutes) * 60
+		if matches[1] == "-" {
+			offset = -offset
+		}
+		return time.FixedZone(timezone, offset), nil
+	}
+
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", timezone, err)
+	}
+	return location, nil
+}
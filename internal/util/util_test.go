@@ -4,6 +4,7 @@ import (
 	"os"
 	"slices"
 	"testing"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -139,6 +140,88 @@ func TestGithubActionsContext(t *testing.T) {
 	}
 }
 
+func TestResolveBaseBranch(t *testing.T) {
+	tests := []struct {
+		name       string
+		preference string
+		flagValue  string
+		env        map[string]string
+		want       string
+		wantErr    bool
+	}{
+		{
+			name:       "flag,default with flag",
+			preference: "flag,default",
+			flagValue:  "develop",
+			want:       "develop",
+		},
+		{
+			name:       "flag,default without flag",
+			preference: "flag,default",
+			env:        map[string]string{"GITHUB_BASE_REF": "release"},
+			want:       "main",
+		},
+		{
+			name:       "flag-only with flag",
+			preference: "flag-only",
+			flagValue:  "develop",
+			want:       "develop",
+		},
+		{
+			name:       "flag-only without flag",
+			preference: "flag-only",
+			wantErr:    true,
+		},
+		{
+			name:       "flag,target with flag",
+			preference: "flag,target",
+			flagValue:  "develop",
+			env:        map[string]string{"GITHUB_BASE_REF": "release"},
+			want:       "develop",
+		},
+		{
+			name:       "flag,target from GitHub Actions",
+			preference: "flag,target",
+			env:        map[string]string{"GITHUB_BASE_REF": "release", "CHANGE_TARGET": "other"},
+			want:       "release",
+		},
+		{
+			name:       "flag,target from Jenkins",
+			preference: "flag,target",
+			env:        map[string]string{"CHANGE_TARGET": "release"},
+			want:       "release",
+		},
+		{
+			name:       "flag,target without flag or target",
+			preference: "flag,target",
+			wantErr:    true,
+		},
+		{
+			name:       "Invalid preference",
+			preference: "default",
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GITHUB_BASE_REF", "")
+			t.Setenv("CHANGE_TARGET", "")
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			got, err := ResolveBaseBranch(tt.preference, tt.flagValue, "main")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveBaseBranch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveBaseBranch() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestIsCommitHash(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -326,6 +409,42 @@ func TestBuildCommitMessage(t *testing.T) {
 			},
 			expectedOutput: "This is a very long commit message title that exceeds seventy-two characters and should trigger a warning",
 		},
+		{
+			name: "Message with subject prefix",
+			viperSettings: map[string]interface{}{
+				"message":        "This is a commit message",
+				"subject-prefix": "[bot] ",
+			},
+			expectedOutput: "[bot] This is a commit message",
+		},
+		{
+			name: "Message with trailing newlines and trailers",
+			viperSettings: map[string]interface{}{
+				"message":        "This is a commit message\n\n",
+				"author.trailer": "Co-Authored-By",
+				"user.name":      "John Doe",
+			},
+			expectedOutput: "This is a commit message\n\nCo-Authored-By: John Doe",
+		},
+		{
+			name: "Message with trailer separator",
+			viperSettings: map[string]interface{}{
+				"message":                   "This is a commit message",
+				"message-trailer-separator": "---",
+				"author.trailer":            "Co-Authored-By",
+				"user.name":                 "John Doe",
+			},
+			expectedOutput: "This is a commit message\n\n---\n\nCo-Authored-By: John Doe",
+		},
+		{
+			name: "Trailer separator without trailers",
+			viperSettings: map[string]interface{}{
+				"message":                   "This is a commit message",
+				"message-trailer-separator": "---",
+				"author.trailer":            "",
+			},
+			expectedOutput: "This is a commit message",
+		},
 		{
 			name: "Message with trailers",
 			viperSettings: map[string]interface{}{
@@ -386,6 +505,81 @@ func TestBuildCommitMessage(t *testing.T) {
 	}
 }
 
+func TestComposeCommitMessage(t *testing.T) {
+	viper.Set("message", "ignored")
+	viper.Set("author.trailer", "Co-Authored-By")
+	viper.Set("user.name", "John Doe")
+	defer viper.Reset()
+
+	expected := "update docs\n\nCo-Authored-By: John Doe"
+	if result := ComposeCommitMessage("update docs"); result != expected {
+		t.Errorf("ComposeCommitMessage() = %v; expected %v", result, expected)
+	}
+}
+
+func TestValidateTrailerSeparator(t *testing.T) {
+	tests := []struct {
+		name      string
+		separator string
+		wantErr   bool
+	}{
+		{name: "Rule", separator: "---"},
+		{name: "Multi-line", separator: "--\nmetadata"},
+		{name: "Blank line", separator: "--\n\n--", wantErr: true},
+		{name: "Whitespace", separator: "  ", wantErr: true},
+		{name: "Trailer-like", separator: "Note: trailers follow", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateTrailerSeparator(tt.separator); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateTrailerSeparator() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPrefixSubject(t *testing.T) {
+	tests := []struct {
+		name           string
+		message        string
+		prefix         string
+		expectedOutput string
+	}{
+		{
+			name:           "No prefix",
+			message:        "update config",
+			expectedOutput: "update config",
+		},
+		{
+			name:           "Prefix",
+			message:        "update config\n\nbody",
+			prefix:         "[ghup] ",
+			expectedOutput: "[ghup] update config\n\nbody",
+		},
+		{
+			name:           "Already prefixed",
+			message:        "[ghup] update config",
+			prefix:         "[ghup] ",
+			expectedOutput: "[ghup] update config",
+		},
+		{
+			name:           "Already prefixed without space",
+			message:        "[ghup]update config",
+			prefix:         "[ghup] ",
+			expectedOutput: "[ghup]update config",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if result := PrefixSubject(tt.message, tt.prefix); result != tt.expectedOutput {
+				t.Errorf("PrefixSubject() = %v; expected %v", result, tt.expectedOutput)
+			}
+		})
+	}
+}
+
 func TestBuildTrailers(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -443,6 +637,22 @@ func TestBuildTrailers(t *testing.T) {
 				"Signed-Off-By: Alice Johnson",
 			},
 		},
+		{
+			name: "Trailers from environment",
+			viperSettings: map[string]interface{}{
+				"trailer": map[string]string{
+					"Reviewed-By": "Jane Smith",
+				},
+				"trailer-from-env": map[string]string{
+					"Build-Id":  "TEST_BUILD_ID",
+					"Build-Url": "TEST_BUILD_URL",
+				},
+			},
+			expectedOutput: []string{
+				"Reviewed-By: Jane Smith",
+				"Build-Id: 42",
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -451,6 +661,9 @@ func TestBuildTrailers(t *testing.T) {
 				viper.Set(key, value)
 			}
 
+			t.Setenv("TEST_BUILD_ID", "42")
+			t.Setenv("TEST_BUILD_URL", "")
+
 			result := BuildTrailers()
 			if !slices.Equal[[]string](result, tt.expectedOutput) {
 				t.Errorf("BuildTrailers() = %v; expected %v", result, tt.expectedOutput)
@@ -460,3 +673,36 @@ func TestBuildTrailers(t *testing.T) {
 		})
 	}
 }
+
+func TestParseTimezone(t *testing.T) {
+	tests := []struct {
+		timezone   string
+		wantOffset int
+		wantErr    bool
+	}{
+		{timezone: "UTC", wantOffset: 0},
+		{timezone: "Asia/Kolkata", wantOffset: 5*3600 + 30*60},
+		{timezone: "+02:00", wantOffset: 2 * 3600},
+		{timezone: "-0530", wantOffset: -(5*3600 + 30*60)},
+		{timezone: "UTC+01:00", wantOffset: 3600},
+		{timezone: "+25:00", wantErr: true},
+		{timezone: "Mars/Olympus_Mons", wantErr: true},
+	}
+
+	// a fixed instant, outside of daylight saving time
+	instant := time.Date(2024, time.January, 15, 12, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.timezone, func(t *testing.T) {
+			location, err := ParseTimezone(tt.timezone)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTimezone(%q) error = %v, wantErr %v", tt.timezone, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if _, offset := instant.In(location).Zone(); offset != tt.wantOffset {
+				t.Errorf("ParseTimezone(%q) offset = %d; expected %d", tt.timezone, offset, tt.wantOffset)
+			}
+		})
+	}
+}